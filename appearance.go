@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	appearanceDark     = "dark"
+	appearanceLight    = "light"
+	appearancePortal   = "portal"
+	appearanceSchedule = "schedule"
+)
+
+const defaultLightHours = "07:00-19:00"
+
+// The desktop color scheme as published by xdg-desktop-portal.
+const (
+	portalName       = "org.freedesktop.portal.Desktop"
+	portalPath       = "/org/freedesktop/portal/desktop"
+	portalSettings   = "org.freedesktop.portal.Settings"
+	appearanceNS     = "org.freedesktop.appearance"
+	colorSchemeKey   = "color-scheme"
+	colorSchemeDark  = 1
+	colorSchemeLight = 2
+)
+
+// watchAppearance switches between the dark and light palette as configured
+// in theme.appearance, following config changes.
+func watchAppearance() {
+	portal := make(chan bool, 1)
+	go func() {
+		var portalStarted, portalLight bool
+		ticker := time.NewTicker(time.Minute)
+		for {
+			next := liveConfig.Next()
+			theme := liveConfig.Get().(config).Theme
+
+			light := false
+			switch theme.Appearance {
+			case appearanceLight:
+				light = true
+			case appearancePortal:
+				if !portalStarted {
+					portalStarted = true
+					go watchPortal(portal)
+				}
+				light = portalLight
+			case appearanceSchedule:
+				light = scheduledLight(theme.LightHours, time.Now())
+			}
+			if lightMode.Swap(light) != light {
+				if err := applyTheme(theme); err != nil {
+					log.Print(err)
+				}
+			}
+
+			select {
+			case portalLight = <-portal:
+			case <-ticker.C:
+			case <-next:
+			}
+		}
+	}()
+}
+
+// scheduledLight reports if now is within hours, e.g. "07:00-19:00".
+func scheduledLight(hours string, now time.Time) bool {
+	if hours == "" {
+		hours = defaultLightHours
+	}
+	start, end, err := parseWorkHours(hours)
+	if err != nil {
+		return false
+	}
+	sinceMidnight := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	return sinceMidnight >= start && sinceMidnight < end
+}
+
+// watchPortal sends the current desktop color scheme to ch, and again on
+// every change. A scheme without preference counts as dark.
+func watchPortal(ch chan<- bool) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(portalPath),
+		dbus.WithMatchInterface(portalSettings),
+		dbus.WithMatchMember("SettingChanged"),
+	); err != nil {
+		log.Print(err)
+		return
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	var scheme dbus.Variant
+	if err := conn.Object(portalName, portalPath).
+		Call(portalSettings+".Read", 0, appearanceNS, colorSchemeKey).
+		Store(&scheme); err != nil {
+		log.Print(err)
+	} else {
+		ch <- isLightScheme(scheme)
+	}
+
+	for sig := range signals {
+		if sig.Name != portalSettings+".SettingChanged" || len(sig.Body) < 3 {
+			continue
+		}
+		ns, _ := sig.Body[0].(string)
+		key, _ := sig.Body[1].(string)
+		if ns != appearanceNS || key != colorSchemeKey {
+			continue
+		}
+		if scheme, ok := sig.Body[2].(dbus.Variant); ok {
+			ch <- isLightScheme(scheme)
+		}
+	}
+}
+
+// isLightScheme unwraps the color-scheme value, which Read returns wrapped in
+// an extra variant.
+func isLightScheme(v dbus.Variant) bool {
+	for {
+		inner, ok := v.Value().(dbus.Variant)
+		if !ok {
+			break
+		}
+		v = inner
+	}
+	scheme, _ := v.Value().(uint32)
+	return scheme == colorSchemeLight
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/pulse"
+)
+
+// sinkIcon guesses the kind of sink from its name.
+func sinkIcon(name string) string {
+	switch {
+	case strings.Contains(name, "bluez"):
+		return "mdi-bluetooth-audio"
+	case strings.Contains(name, "hdmi"):
+		return "mdi-television"
+	default:
+		return "mdi-speaker"
+	}
+}
+
+// audioSwitch holds the error of the last switch to another sink, if any.
+type audioSwitch struct {
+	mu       sync.Mutex
+	err      error
+	rerender func()
+}
+
+func (a *audioSwitch) get() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// activate makes d the default sink, taking everything that plays along.
+func (a *audioSwitch) activate(d pulse.Device) {
+	err := d.Activate()
+	if err != nil {
+		l.Log("audio output: %v", err)
+	}
+	a.mu.Lock()
+	a.err = err
+	a.mu.Unlock()
+	a.rerender()
+}
+
+func audioOutputs() bar.Module {
+	sinks := pulse.Sinks()
+	switcher := &audioSwitch{}
+	var format func(pulse.DeviceList) bar.Output
+	switcher.rerender = func() { sinks.Output(format) }
+
+	format = func(list pulse.DeviceList) bar.Output {
+		out := outputs.Group()
+		for _, d := range list.Devices {
+			sink := outputs.Pango(fontIcon(sinkIcon(d.Name)).Alpha(0.6), spacer, pango.Text(truncate(d.Description, 24)).Small())
+			if d.Name == list.Default {
+				out.Append(sink.Color(colorAccent))
+				continue
+			}
+			out.Append(sink.Color(colorMuted).OnClick(click.Left(func() {
+				// pactl calls block, keep them off the event loop
+				go switcher.activate(d)
+			})))
+		}
+		if err := switcher.get(); err != nil {
+			out.Append(outputs.Text(truncate(err.Error(), 40)).Color(colorAlert))
+		}
+		return out
+	}
+	return sinks.Output(format)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"github.com/barista-run/barista/bar"
-	"github.com/barista-run/barista/colors"
 	"github.com/barista-run/barista/modules/bluetooth"
 	"github.com/barista-run/barista/modules/meta/split"
 	"github.com/barista-run/barista/outputs"
@@ -10,13 +9,14 @@ import (
 )
 
 func bluetoothAudio(adapter, address, icon string) (bar.Module, bar.Module) {
-	return split.New(bluetooth.Device(adapter, address).Output(func(b bluetooth.DeviceInfo) bar.Output {
+	device := bluetooth.Device(adapter, address)
+	format := func(b bluetooth.DeviceInfo) bar.Output {
 
 		out := outputs.Group()
 
 		switch b.Connected {
 		case true:
-			color := colorOn
+			color := colorOk
 			iconAppendix := ""
 
 			// to get the battery status
@@ -24,38 +24,40 @@ func bluetoothAudio(adapter, address, icon string) (bar.Module, bar.Module) {
 			//
 			// change icon color if battery is low
 			if b.Battery <= 20 {
-				color = colorBatteryLow
+				color = colorWarn
 			}
 
 			// summary
 			out.Append(outputs.Pango(
-				pango.Icon("mdi-" + icon + iconAppendix).Alpha(0.6).Color(colors.Hex(color)),
+				fontIcon("mdi-"+icon+iconAppendix).Alpha(0.6).Color(color),
+				stateCue(b.Connected),
 			))
 
 			// detail
 			out.Append(outputs.Pango(
-				pango.Icon("mdi-"+icon).Alpha(0.6).Color(colors.Hex(color)),
+				fontIcon("mdi-"+icon).Alpha(0.6).Color(color),
 				spacer,
 				pango.Text(b.Name),
 			))
 
 			out.Append(outputs.Pango(
-				pango.Icon("mdi-battery").Alpha(0.6),
+				fontIcon("mdi-battery").Alpha(0.6),
 				pango.Textf("%d%%", b.Battery),
 			))
 
 		case false:
-			color := colorOff
+			color := colorAlert
 			iconAppendix := "-off"
 
 			// summary
 			out.Append(outputs.Pango(
-				pango.Icon("mdi-" + icon + iconAppendix).Alpha(0.6).Color(colors.Hex(color)),
+				fontIcon("mdi-"+icon+iconAppendix).Alpha(0.6).Color(color),
+				stateCue(b.Connected),
 			))
 
 			// detail
 			out.Append(outputs.Pango(
-				pango.Icon("mdi-"+icon).Alpha(0.6).Color(colors.Hex(color)),
+				fontIcon("mdi-"+icon).Alpha(0.6).Color(color),
 				spacer,
 				pango.Text(b.Name),
 			))
@@ -63,5 +65,7 @@ func bluetoothAudio(adapter, address, icon string) (bar.Module, bar.Module) {
 		}
 
 		return out
-	}), 1)
+	}
+	onThemeChange(func() { device.Output(format) })
+	return split.New(device.Output(format), 1)
 }
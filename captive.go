@@ -0,0 +1,138 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// e.g.:
+// captivePortal:
+//
+//	url: http://nmcheck.gnome.org/check_network_status.txt
+//	status: 200
+//	body: NetworkManager is online
+//
+// After connecting to a WiFi network, url is fetched without following
+// redirects. Any other response than the expected status (and body, if set)
+// means a captive portal intercepts the traffic.
+type captiveConfig struct {
+	URL    string `koanf:"url"`
+	Status int    `koanf:"status"`
+	Body   string `koanf:"body"`
+}
+
+const defaultCaptiveURL = "http://connectivitycheck.gstatic.com/generate_204"
+
+type portalState int
+
+const (
+	portalUnknown portalState = iota
+	portalChecking
+	portalOnline
+	portalCaptive
+)
+
+// captivePortal checks each network once after connecting, and keeps
+// checking every captiveRecheck while it is captive to notice the login. A
+// check that fails altogether, e.g. while DHCP or DNS aren't ready yet, is
+// retried with a backoff from captiveRetryMin to captiveRetryMax.
+type captivePortal struct {
+	mu      sync.Mutex
+	network string
+	// gen counts network changes, checks of an earlier one are dropped
+	gen      int
+	state    portalState
+	login    string
+	rerender func()
+}
+
+const (
+	captiveRecheck  = 30 * time.Second
+	captiveRetryMin = 5 * time.Second
+	captiveRetryMax = 5 * time.Minute
+)
+
+// status returns the portal state of network and the page to log in, starting
+// a check if network changed.
+func (c *captivePortal) status(network string) (portalState, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if network != c.network {
+		c.network, c.state, c.login = network, portalChecking, ""
+		c.gen++
+		go c.check(c.gen, captiveRetryMin)
+	}
+	return c.state, c.login
+}
+
+// reset forgets the network, so the next connect is checked again.
+func (c *captivePortal) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.network, c.state, c.login = "", portalUnknown, ""
+	c.gen++
+}
+
+// check checks the network of generation gen, retrying after retry if the
+// result is unknown.
+func (c *captivePortal) check(gen int, retry time.Duration) {
+	state, login := checkCaptivePortal(liveConfig.Get().(config).CaptivePortal)
+
+	c.mu.Lock()
+	if c.gen != gen {
+		c.mu.Unlock()
+		return
+	}
+	c.state, c.login = state, login
+	c.mu.Unlock()
+	c.rerender()
+
+	switch state {
+	case portalCaptive:
+		time.AfterFunc(captiveRecheck, func() { c.check(gen, captiveRetryMin) })
+	case portalUnknown:
+		time.AfterFunc(retry, func() { c.check(gen, min(2*retry, captiveRetryMax)) })
+	}
+}
+
+func checkCaptivePortal(cfg captiveConfig) (portalState, string) {
+	if cfg.URL == "" {
+		cfg.URL = defaultCaptiveURL
+	}
+	if cfg.Status == 0 {
+		cfg.Status = http.StatusNoContent
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(cfg.URL)
+	if err != nil {
+		// not even the portal answers
+		return portalUnknown, ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if login := resp.Header.Get("Location"); login != "" {
+			return portalCaptive, login
+		}
+		return portalCaptive, cfg.URL
+	}
+	if resp.StatusCode != cfg.Status {
+		return portalCaptive, cfg.URL
+	}
+	if cfg.Body != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err != nil || strings.TrimSpace(string(body)) != cfg.Body {
+			return portalCaptive, cfg.URL
+		}
+	}
+	return portalOnline, ""
+}
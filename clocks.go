@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/barista-run/barista/timing"
+)
+
+// e.g.:
+// timezones:
+//   - label: Seattle
+//     tz: America/Los_Angeles
+//     workHours: 09:00-17:00
+type timezoneConfig struct {
+	Label     string `koanf:"label"`
+	TZ        string `koanf:"tz"`
+	WorkHours string `koanf:"workHours"`
+}
+
+func defaultTimezones() []timezoneConfig {
+	return []timezoneConfig{
+		{Label: "Seattle", TZ: "America/Los_Angeles"},
+		{Label: "New York", TZ: "America/New_York"},
+		{Label: "UTC", TZ: "Etc/UTC"},
+		{Label: "Berlin", TZ: "Europe/Berlin"},
+		{Label: "Bangalore", TZ: "Asia/Kolkata"},
+	}
+}
+
+// parseWorkHours parses "09:00-17:00" into offsets from midnight.
+func parseWorkHours(s string) (start, end time.Duration, err error) {
+	var h1, m1, h2, m2 int
+	if _, err := fmt.Sscanf(s, "%d:%d-%d:%d", &h1, &m1, &h2, &m2); err != nil {
+		return 0, 0, fmt.Errorf("invalid work hours %q, want e.g. 09:00-17:00", s)
+	}
+	start = time.Duration(h1)*time.Hour + time.Duration(m1)*time.Minute
+	end = time.Duration(h2)*time.Hour + time.Duration(m2)*time.Minute
+	if start >= end || end > 24*time.Hour {
+		return 0, 0, fmt.Errorf("invalid work hours %q", s)
+	}
+	return start, end, nil
+}
+
+// dayOffset returns the number of calendar days t is ahead of (or behind)
+// local time.
+func dayOffset(t time.Time) int {
+	y, m, d := t.Date()
+	ly, lm, ld := t.Local().Date()
+	there := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	here := time.Date(ly, lm, ld, 0, 0, 0, 0, time.UTC)
+	return int(there.Sub(here).Hours() / 24)
+}
+
+type tzClocksModule struct {
+	zones []timezoneConfig
+	done  <-chan struct{}
+}
+
+// timezoneClocks shows the time in each configured timezone, updated every
+// minute until done is closed.
+func timezoneClocks(cfg config, done <-chan struct{}) bar.Module {
+	return tzClocksModule{cfg.Timezones, done}
+}
+
+func (m tzClocksModule) Stream(s bar.Sink) {
+	locations := make([]*time.Location, len(m.zones))
+	for i, tz := range m.zones {
+		// an unknown timezone stays nil and is shown as such
+		locations[i], _ = time.LoadLocation(tz.TZ)
+	}
+
+	scheduler := timing.NewScheduler().EveryAlign(time.Minute, 0)
+	defer scheduler.Close()
+
+	for {
+		now := timing.Now()
+		out := outputs.Group()
+		for i, tz := range m.zones {
+			out.Append(tzClock(tz, locations[i], now))
+		}
+		s.Output(out)
+
+		select {
+		case <-scheduler.C:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func tzClock(tz timezoneConfig, loc *time.Location, now time.Time) bar.Output {
+	if loc == nil {
+		return outputs.Pango(pango.Text(tz.Label).Smaller(), spacer, "?").
+			Color(colorAlert)
+	}
+	now = now.In(loc)
+
+	nodes := []interface{}{pango.Text(tz.Label).Smaller(), spacer, now.Format("15:04")}
+	if offset := dayOffset(now); offset != 0 {
+		nodes = append(nodes, spacer, pango.Textf("%+dd", offset).Small())
+	}
+	out := outputs.Pango(nodes...)
+
+	start, end, err := parseWorkHours(tz.WorkHours)
+	if tz.WorkHours == "" || err != nil {
+		return out
+	}
+
+	// an hour around the working hours colleagues might still be around
+	sinceMidnight := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	weekend := now.Weekday() == time.Saturday || now.Weekday() == time.Sunday
+	return threshold(out, false,
+		weekend || sinceMidnight < start-time.Hour || sinceMidnight >= end+time.Hour,
+		sinceMidnight < start || sinceMidnight >= end,
+		true,
+	)
+}
@@ -1,8 +1,294 @@
 package main
 
-const (
-	colorOn  = "#34eb55"
-	colorOff = "#eb4034"
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/barista-run/barista/base/value"
+
+	"github.com/barista-run/barista/colors"
+	"github.com/barista-run/barista/pango"
+	colorful "github.com/lucasb-eyer/go-colorful"
+)
 
-	colorBatteryLow = "#ffae34"
+// paletteColor is a palette role. It is a color.Color itself and resolves to
+// the current palette whenever the bar is written, so segment colors follow
+// theme changes without re-rendering.
+type paletteColor string
+
+// Palette roles.
+const (
+	colorOk     paletteColor = "ok"
+	colorAlert  paletteColor = "alert"
+	colorWarn   paletteColor = "warn"
+	colorAccent paletteColor = "accent"
+	colorMuted  paletteColor = "muted"
 )
+
+// currentPalette holds the applied palette, starting with the default one
+// until the theme is applied.
+var currentPalette atomic.Pointer[map[paletteColor]colors.ColorfulColor]
+
+func init() {
+	palette := map[paletteColor]colors.ColorfulColor{}
+	for role, hex := range palettes["default"] {
+		palette[role] = colors.Hex(hex)
+	}
+	currentPalette.Store(&palette)
+}
+
+func (c paletteColor) RGBA() (r, g, b, a uint32) {
+	return (*currentPalette.Load())[c].RGBA()
+}
+
+// barBackground and barStatusline are the colors of the i3 bar config. They
+// are read once before the bar starts, colors.Scheme is not safe to use
+// while it might be written.
+var barBackground, barStatusline colors.ColorfulColor
+
+func loadBarColors() {
+	colors.LoadBarConfig()
+	barBackground = colors.Scheme("background")
+	barStatusline = colors.Scheme("statusline")
+}
+
+// themeChanged is set after every applyTheme.
+var themeChanged value.Value
+
+// onThemeChange calls fn after every theme change. Outputs with colors baked
+// into pango markup use it to render again.
+func onThemeChange(fn func()) {
+	onSet(&themeChanged, fn)
+}
+
+// onSet calls fn whenever v is set.
+func onSet(v *value.Value, fn func()) {
+	go func() {
+		next := v.Next()
+		for {
+			<-next
+			next = v.Next()
+			fn()
+		}
+	}()
+}
+
+// e.g.:
+// theme:
+//
+//	palette: deuteranopia
+//	shapes: true
+//	appearance: schedule
+//	lightHours: 07:00-19:00
+//	ok: "#34eb55"
+//	alert: "#eb4034"
+//	warn: "#ffae34"
+//	accent: "#ff7700"
+//	muted: "#888888"
+//	light:
+//	  ok: "#1b7a2e"
+//	  accent: "#b34700"
+//
+// palette selects one of the palettes below as base, any role set explicitly
+// overrides it. With shapes enabled, on/off states get an extra check/cross
+// icon so they don't depend on color alone.
+//
+// appearance is dark (default), light, portal to follow the desktop color
+// scheme, or schedule to use the light variant during lightHours. The light
+// variant of a palette is taken from lightPalettes, or else derived by
+// darkening the palette to stay readable on a light bar. Roles set in dark or
+// light override the palette and the top-level roles for that variant only.
+type themeConfig struct {
+	Palette    string      `koanf:"palette"`
+	Shapes     bool        `koanf:"shapes"`
+	Appearance string      `koanf:"appearance"`
+	LightHours string      `koanf:"lightHours"`
+	OK         string      `koanf:"ok"`
+	Alert      string      `koanf:"alert"`
+	Warn       string      `koanf:"warn"`
+	Accent     string      `koanf:"accent"`
+	Muted      string      `koanf:"muted"`
+	Dark       themeColors `koanf:"dark"`
+	Light      themeColors `koanf:"light"`
+}
+
+// themeColors overrides palette roles for one variant.
+type themeColors struct {
+	OK     string `koanf:"ok"`
+	Alert  string `koanf:"alert"`
+	Warn   string `koanf:"warn"`
+	Accent string `koanf:"accent"`
+	Muted  string `koanf:"muted"`
+}
+
+func (c themeColors) roles() map[paletteColor]string {
+	return map[paletteColor]string{
+		colorOk:     c.OK,
+		colorAlert:  c.Alert,
+		colorWarn:   c.Warn,
+		colorAccent: c.Accent,
+		colorMuted:  c.Muted,
+	}
+}
+
+// roleColors returns the top-level roles, shared by both variants.
+func (t themeConfig) roleColors() themeColors {
+	return themeColors{t.OK, t.Alert, t.Warn, t.Accent, t.Muted}
+}
+
+// overrides returns the roles set for the current variant, those of the
+// variant taking precedence over the top-level ones.
+func (t themeConfig) overrides(light bool) map[paletteColor]string {
+	roles := t.roleColors().roles()
+	variant := t.Dark
+	if light {
+		variant = t.Light
+	}
+	for role, hex := range variant.roles() {
+		if hex != "" {
+			roles[role] = hex
+		}
+	}
+	return roles
+}
+
+var palettes = map[string]map[paletteColor]string{
+	"default": {
+		colorOk:     "#34eb55",
+		colorAlert:  "#eb4034",
+		colorWarn:   "#ffae34",
+		colorAccent: "#ff7700",
+		colorMuted:  "#888888",
+	},
+	// Okabe-Ito colors, distinguishable with red-green color blindness
+	"deuteranopia": {
+		colorOk:     "#56b4e9",
+		colorAlert:  "#d55e00",
+		colorWarn:   "#f0e442",
+		colorAccent: "#cc79a7",
+		colorMuted:  "#999999",
+	},
+	"high-contrast": {
+		colorOk:     "#00e5ff",
+		colorAlert:  "#ff1744",
+		colorWarn:   "#ffea00",
+		colorAccent: "#ffffff",
+		colorMuted:  "#bdbdbd",
+	},
+}
+
+// lightPalettes are hand-picked light variants of palettes whose colors
+// don't survive plain darkening.
+var lightPalettes = map[string]map[paletteColor]string{
+	// darker Okabe-Ito colors, yellow turns orange to stay visible
+	"deuteranopia": {
+		colorOk:     "#0072b2",
+		colorAlert:  "#d55e00",
+		colorWarn:   "#e69f00",
+		colorAccent: "#a3487a",
+		colorMuted:  "#666666",
+	},
+	"high-contrast": {
+		colorOk:     "#006064",
+		colorAlert:  "#b71c1c",
+		colorWarn:   "#7a5c00",
+		colorAccent: "#000000",
+		colorMuted:  "#424242",
+	},
+}
+
+// lightMode is set if the light variant of the palette is in use, see
+// watchAppearance.
+var lightMode atomic.Bool
+
+// shapeCues is set if on/off states should be marked by an icon as well.
+var shapeCues atomic.Bool
+
+// stateCue returns a check or cross icon for on/off states if shape cues are
+// enabled, and an empty node otherwise.
+func stateCue(on bool) *pango.Node {
+	if !shapeCues.Load() {
+		return pango.Text("")
+	}
+	if on {
+		return fontIcon("mdi-check-circle-outline").Small()
+	}
+	return fontIcon("mdi-close-circle-outline").Small()
+}
+
+// defaultPalette returns the named palette, or its light variant if light is
+// set. For the default palette, if the bar config provides a statusline
+// color, ok/warn/alert are derived from its brightness so they stay readable
+// on the bar.
+func defaultPalette(name string, light bool) (map[paletteColor]colors.ColorfulColor, error) {
+	if name == "" {
+		name = "default"
+	}
+	hexes, ok := palettes[name]
+	if !ok {
+		return nil, fmt.Errorf("theme: unknown palette %q", name)
+	}
+
+	palette := map[paletteColor]colors.ColorfulColor{}
+	if lightHexes, ok := lightPalettes[name]; ok && light {
+		for role, hex := range lightHexes {
+			palette[role] = colors.Hex(hex)
+		}
+		return palette, nil
+	}
+
+	for role, hex := range hexes {
+		palette[role] = colors.Hex(hex)
+	}
+	if name == "default" && barStatusline != nil && barBackground != nil {
+		_, _, v := barStatusline.Colorful().Hsv()
+		if v < 0.3 {
+			v = 0.3
+		}
+		palette[colorAlert] = colors.Hex(colorful.Hcl(40, 1.0, v).Clamped().Hex())
+		palette[colorWarn] = colors.Hex(colorful.Hcl(90, 1.0, v).Clamped().Hex())
+		palette[colorOk] = colors.Hex(colorful.Hcl(120, 1.0, v).Clamped().Hex())
+	}
+	if light {
+		for role, c := range palette {
+			palette[role] = forLightBackground(c)
+		}
+	}
+	return palette, nil
+}
+
+// applyTheme makes the palette of cfg the current one. Colors are only
+// looked up through currentPalette, barista's color scheme is never written
+// once the bar runs.
+func applyTheme(cfg themeConfig) error {
+	light := lightMode.Load()
+	palette, err := defaultPalette(cfg.Palette, light)
+	if err != nil {
+		return err
+	}
+
+	for role, hex := range cfg.overrides(light) {
+		if hex == "" {
+			continue
+		}
+		c := colors.Hex(hex)
+		if c == nil {
+			return fmt.Errorf("theme: invalid %s color %q", role, hex)
+		}
+		palette[role] = c
+	}
+
+	currentPalette.Store(&palette)
+	shapeCues.Store(cfg.Shapes)
+	themeChanged.Set(palette)
+	return nil
+}
+
+// forLightBackground caps the luminance of c.
+func forLightBackground(c colors.ColorfulColor) colors.ColorfulColor {
+	h, chroma, l := c.Colorful().Hcl()
+	if l > 0.5 {
+		l = 0.5
+	}
+	return colors.Hex(colorful.Hcl(h, chroma, l).Clamped().Hex())
+}
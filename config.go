@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/barista-run/barista/base/value"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+type config struct {
+	Jira struct {
+		URL   string `koanf:"url"`
+		User  string `koanf:"user"`
+		Token string `koanf:"token"`
+		// SearchPath overrides the search endpoint, e.g. "/rest/api/2/search"
+		// for Jira Server/Data Center.
+		SearchPath string            `koanf:"searchPath"`
+		OpenURL    string            `koanf:"openURL"`
+		Icon       string            `koanf:"icon"`
+		Queries    []jiraQueryConfig `koanf:"queries"`
+	} `koanf:"jira"`
+	Forges        []forgeConfig    `koanf:"forges"`
+	Layout        layoutConfig     `koanf:"layout"`
+	Timezones     []timezoneConfig `koanf:"timezones"`
+	Tunnels       []tunnelConfig   `koanf:"tunnels"`
+	Probes        []probeConfig    `koanf:"probes"`
+	CaptivePortal captiveConfig    `koanf:"captivePortal"`
+	Media         mediaConfig      `koanf:"media"`
+	Icons         iconsConfig      `koanf:"icons"`
+	Theme         themeConfig      `koanf:"theme"`
+}
+
+// jiraConfigured reports whether any of the Jira modules is set up.
+func (c config) jiraConfigured() bool {
+	return c.Jira.URL != "" || c.Jira.Token != "" || len(c.Jira.Queries) > 0
+}
+
+type forgeConfig struct {
+	Host    string `koanf:"host"`
+	OpenURL string `koanf:"openURL"`
+	Icon    string `koanf:"icon"`
+}
+
+// liveConfig holds the last config that loaded successfully, configErr the
+// error of the most recent load attempt, if any.
+var (
+	liveConfig value.Value // of config
+	configErr  value.Value // of error
+)
+
+var cfgPath = home(".config/i3/config.yaml")
+
+func loadConfig(path string) (config, error) {
+	cfg := config{}
+
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		return cfg, fmt.Errorf("error loading config: %w", err)
+	}
+	if err := resolveSecrets(k); err != nil {
+		return cfg, fmt.Errorf("error resolving secrets: %w", err)
+	}
+	if err := k.Unmarshal("", &cfg); err != nil {
+		return cfg, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	defaults := defaultLayout()
+	if len(cfg.Layout.Modes) == 0 {
+		cfg.Layout.Modes = defaults.Modes
+	}
+	if cfg.Layout.Right == nil {
+		cfg.Layout.Right = defaults.Right
+	}
+	if len(cfg.Timezones) == 0 {
+		cfg.Timezones = defaultTimezones()
+	}
+	if len(cfg.Tunnels) == 0 {
+		cfg.Tunnels = defaultTunnels()
+	}
+	if cfg.Probes == nil {
+		cfg.Probes = defaultProbes()
+	}
+	return cfg, nil
+}
+
+// watchConfig reloads the config whenever the file changes. A config that
+// fails to load is reported through configErr and otherwise ignored, so the
+// bar keeps running with the previous one. It reports whether the watch
+// started, otherwise it is retried.
+func watchConfig(path string) bool {
+	f := file.Provider(path)
+	err := f.Watch(func(_ interface{}, err error) {
+		if err != nil {
+			// the watch ends on errors, e.g. when an editor replaces the
+			// file; start over once it is back
+			configErr.Set(err)
+			_ = f.Unwatch()
+			time.AfterFunc(5*time.Second, func() { rewatchConfig(path) })
+			return
+		}
+		reloadConfig(path)
+	})
+	if err != nil {
+		log.Print(err)
+		configErr.Set(err)
+		time.AfterFunc(5*time.Second, func() { rewatchConfig(path) })
+		return false
+	}
+	return true
+}
+
+// rewatchConfig watches the config again after the watch ended. The file was
+// most likely replaced in the meantime, so it is loaded right away.
+func rewatchConfig(path string) {
+	if watchConfig(path) {
+		reloadConfig(path)
+	}
+}
+
+// reloadConfig loads the config and makes it the live one, or reports why it
+// failed to load.
+func reloadConfig(path string) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		log.Print(err)
+		configErr.Set(err)
+		return
+	}
+	if err := applyTheme(cfg.Theme); err != nil {
+		configErr.Set(err)
+		return
+	}
+	configErr.Set(nil)
+	liveConfig.Set(cfg)
+}
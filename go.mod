@@ -5,11 +5,15 @@ go 1.26
 require (
 	github.com/barista-run/barista v0.0.0-20260623135021-0c6766db5ca0
 	github.com/git-pkgs/forge v0.5.1
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/knadh/koanf/parsers/yaml v1.1.0
 	github.com/knadh/koanf/providers/file v1.2.1
 	github.com/knadh/koanf/v2 v2.3.5
 	github.com/lucasb-eyer/go-colorful v1.2.0
 	github.com/martinlindhe/unit v0.0.0-20230420213220-4adfd7d0a0d6
+	github.com/vishvananda/netlink v1.1.0
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/text v0.37.0
 )
 
 require (
@@ -18,18 +22,15 @@ require (
 	github.com/git-pkgs/purl v0.1.12 // indirect
 	github.com/git-pkgs/vers v0.2.5 // indirect
 	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/knadh/koanf/maps v0.1.2 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	github.com/package-url/packageurl-go v0.1.6 // indirect
 	github.com/spf13/afero v1.15.0 // indirect
-	github.com/vishvananda/netlink v1.1.0 // indirect
 	github.com/vishvananda/netns v0.0.4 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/crypto v0.52.0 // indirect
 	golang.org/x/oauth2 v0.35.0 // indirect
 	golang.org/x/sys v0.45.0 // indirect
-	golang.org/x/text v0.37.0 // indirect
 	golang.org/x/time v0.14.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
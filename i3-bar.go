@@ -16,7 +16,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -25,70 +24,101 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/barista-run/barista"
 	"github.com/barista-run/barista/bar"
 	"github.com/barista-run/barista/base/click"
-	"github.com/barista-run/barista/base/watchers/netlink"
-	"github.com/barista-run/barista/colors"
 	"github.com/barista-run/barista/format"
-	"github.com/barista-run/barista/group/modal"
+	l "github.com/barista-run/barista/logging"
 	"github.com/barista-run/barista/modules/battery"
 	"github.com/barista-run/barista/modules/clock"
 	"github.com/barista-run/barista/modules/diskio"
 	"github.com/barista-run/barista/modules/diskspace"
 	"github.com/barista-run/barista/modules/media"
 	"github.com/barista-run/barista/modules/meta/split"
-	"github.com/barista-run/barista/modules/netinfo"
-	"github.com/barista-run/barista/modules/netspeed"
-	"github.com/barista-run/barista/modules/shell"
-
-	"github.com/barista-run/barista/modules/volume"
-	"github.com/barista-run/barista/modules/volume/alsa" // libasound2-dev or libsdl2-dev
-	"github.com/barista-run/barista/modules/wlan"
 	"github.com/barista-run/barista/outputs"
 	"github.com/barista-run/barista/pango"
-	"github.com/barista-run/barista/pango/icons/mdi"
-
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/v2"
+	"golang.org/x/text/width"
 
-	forge "github.com/git-pkgs/forge"
-
-	colorful "github.com/lucasb-eyer/go-colorful"
+	"github.com/bavarianbidi/i3-bar/pulse"
+	"github.com/bavarianbidi/i3-bar/routespeed"
 )
 
-type config struct {
-	Jira struct {
-		Token   string `koanf:"token"`
-		OpenURL string `koanf:"openURL"`
-		Icon    string `koanf:"icon"`
-	} `koanf:"jira"`
-	Forges []struct {
-		Host    string `koanf:"host"`
-		OpenURL string `koanf:"openURL"`
-		Icon    string `koanf:"icon"`
-	} `koanf:"forges"`
+var spacer = pango.Text(" ").XXSmall()
+
+// runeWidth returns the number of columns r takes up: 2 for wide characters
+// like CJK and most emoji, 0 for combining marks and 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), r == '\u200d', unicode.Is(unicode.Variation_Selector, r):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
 }
 
-var spacer = pango.Text(" ").XXSmall()
-var mainModalController modal.Controller
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
 
+// truncate shortens in to at most l columns, marking the cut with an
+// ellipsis. With a negative l, the start is cut instead of the end.
 func truncate(in string, l int) string {
 	fromStart := false
 	if l < 0 {
 		fromStart = true
 		l = -l
 	}
-	inLen := len([]rune(in))
-	if inLen <= l {
+	if displayWidth(in) <= l {
 		return in
 	}
+	runes := []rune(in)
 	if fromStart {
-		return "⋯" + string([]rune(in)[inLen-l+1:])
+		w, i := 1, len(runes)
+		for i > 0 && w+runeWidth(runes[i-1]) <= l {
+			i--
+			w += runeWidth(runes[i])
+		}
+		return "⋯" + string(runes[i:])
+	}
+	w, i := 1, 0
+	for i < len(runes) && w+runeWidth(runes[i]) <= l {
+		w += runeWidth(runes[i])
+		i++
 	}
-	return string([]rune(in)[:l-1]) + "⋯"
+	return string(runes[:i]) + "⋯"
+}
+
+// marquee scrolls text through a window of cols columns, one character per
+// step. Text that fits isn't scrolled.
+func marquee(text string, cols int, step time.Duration) bar.Output {
+	if displayWidth(text) <= cols {
+		return outputs.Text(text)
+	}
+	runes := []rune(text + "   ")
+	return outputs.Repeat(func(now time.Time) bar.Output {
+		offset := int(now.UnixNano()/int64(step)) % len(runes)
+		var window []rune
+		w := 0
+		for i := 0; w+runeWidth(runes[(offset+i)%len(runes)]) <= cols; i++ {
+			r := runes[(offset+i)%len(runes)]
+			window = append(window, r)
+			w += runeWidth(r)
+		}
+		// keep the width steady when a wide character doesn't fit
+		for ; w < cols; w++ {
+			window = append(window, ' ')
+		}
+		return outputs.Text(string(window))
+	}).Every(step)
 }
 
 func hms(d time.Duration) (h int, m int, s int) {
@@ -107,7 +137,7 @@ func formatMediaTime(d time.Duration) string {
 }
 
 func makeMediaIconAndPosition(m media.Info) *pango.Node {
-	iconAndPosition := pango.Icon("mdi-music-circle").Color(colors.Hex("#f70"))
+	iconAndPosition := fontIcon("mdi-music-circle").Color(colorAccent)
 	if m.PlaybackStatus == media.Playing {
 		iconAndPosition.Append(spacer,
 			pango.Textf("%s/", formatMediaTime(m.Position())))
@@ -124,9 +154,9 @@ func mediaFormatFunc(m media.Info) bar.Output {
 		return nil
 	}
 	artist := truncate(m.Artist, 35)
-	title := truncate(m.Title, 70-len(artist))
-	if len(title) < 35 {
-		artist = truncate(m.Artist, 35-len(title))
+	title := truncate(m.Title, 70-displayWidth(artist))
+	if displayWidth(title) < 35 {
+		artist = truncate(m.Artist, 35-displayWidth(title))
 	}
 	var iconAndPosition bar.Output
 	if m.PlaybackStatus == media.Playing {
@@ -136,7 +166,63 @@ func mediaFormatFunc(m media.Info) bar.Output {
 	} else {
 		iconAndPosition = makeMediaIconAndPosition(m)
 	}
-	return outputs.Group(iconAndPosition, outputs.Pango(title, " - ", artist))
+	var text bar.Output = outputs.Pango(title, " - ", artist)
+	if cfg := liveConfig.Get().(config).Media.Marquee; cfg.Width > 0 {
+		if cfg.Speed <= 0 {
+			cfg.Speed = 500 * time.Millisecond
+		}
+		text = outputs.Text(truncate(m.Title+" - "+m.Artist, cfg.Width))
+		if m.PlaybackStatus == media.Playing {
+			text = marquee(m.Title+" - "+m.Artist, cfg.Width, cfg.Speed)
+		}
+	}
+	playerName, _, _ := strings.Cut(m.PlayerName, ".")
+	return outputs.Group(iconAndPosition, text,
+		outputs.Pango(fontIcon("mdi-play-box-outline").Alpha(0.6), spacer, pango.Text(playerName).Small()),
+		makeMediaModes(m),
+	).OnClick(mediaClickHandler(m))
+}
+
+// mediaClickHandler plays/pauses on left click, switches tracks on scroll and
+// seeks forward on right click.
+func mediaClickHandler(m media.Info) func(bar.Event) {
+	return func(e bar.Event) {
+		switch e.Button {
+		case bar.ButtonLeft:
+			m.PlayPause()
+		case bar.ScrollUp, bar.ScrollLeft:
+			m.Previous()
+		case bar.ScrollDown, bar.ScrollRight:
+			m.Next()
+		case bar.ButtonRight:
+			m.Seek(10 * time.Second)
+		}
+	}
+}
+
+// makeMediaModes shows shuffle and repeat, clicking them toggles shuffle or
+// cycles through the repeat modes.
+func makeMediaModes(m media.Info) bar.Output {
+	shuffle := fontIcon("mdi-shuffle-disabled").Alpha(0.6)
+	if m.Shuffle {
+		shuffle = fontIcon("mdi-shuffle")
+	}
+	loop, _ := mprisLoop.Get().(string)
+	repeat := fontIcon("mdi-repeat-off").Alpha(0.6)
+	switch loop {
+	case loopPlaylist:
+		repeat = fontIcon("mdi-repeat")
+	case loopTrack:
+		repeat = fontIcon("mdi-repeat-once")
+	}
+	return outputs.Group(
+		outputs.Pango(shuffle).OnClick(click.Left(func() {
+			setMprisProperty(m.PlayerName, "Shuffle", !m.Shuffle)
+		})),
+		outputs.Pango(repeat).OnClick(click.Left(func() {
+			setMprisProperty(m.PlayerName, "LoopStatus", nextLoopStatus(loop))
+		})),
+	)
 }
 
 func home(path ...string) string {
@@ -164,61 +250,67 @@ func deviceForMountPath(path string) string {
 }
 
 func makeIconOutput(key string) *bar.Segment {
-	return outputs.Pango(spacer, pango.Icon(key), spacer)
+	return outputs.Pango(spacer, fontIcon(key), spacer)
 }
 
 func threshold(out *bar.Segment, urgent bool, color ...bool) *bar.Segment {
 	if urgent {
 		return out.Urgent(true)
 	}
-	colorKeys := []string{"bad", "degraded", "good"}
+	colorKeys := []paletteColor{colorAlert, colorWarn, colorOk}
 	for i, c := range colorKeys {
 		if len(color) > i && color[i] {
-			return out.Color(colors.Scheme(c))
+			return out.Color(c)
 		}
 	}
 	return out
 }
 
 func main() {
-	err := mdi.Load(home("go/src/github.com/Templarian/MaterialDesign-Webfont"))
-	if err != nil {
-		log.Fatal(err)
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validate())
 	}
 
-	homeDir, err := os.UserHomeDir()
+	// read config file
+	cfg, err := loadConfig(cfgPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// read config file
-	k := koanf.New(".")
-	if err := k.Load(file.Provider(homeDir+"/.config/i3/config.yaml"), yaml.Parser()); err != nil {
-		log.Fatalf("error loading config: %v", err)
+	if err := loadIcons(cfg.Icons); err != nil {
+		log.Print(err)
 	}
+	loadBarColors()
+	liveConfig.Set(cfg)
+	watchConfig(cfgPath)
 
-	cfg := config{}
-	if err := k.Unmarshal("", &cfg); err != nil {
-		log.Fatalf("error unmarshaling config: %v", err)
+	if err := applyTheme(cfg.Theme); err != nil {
+		log.Print(err)
 	}
-
-	colors.LoadBarConfig()
-	bg := colors.Scheme("background")
-	fg := colors.Scheme("statusline")
-	if fg != nil && bg != nil {
-		_, _, v := fg.Colorful().Hsv()
-		if v < 0.3 {
-			v = 0.3
+	watchAppearance()
+
+	modules := barModules()
+	shared := make(map[string]*sharedModule, len(modules))
+	for name, m := range modules {
+		shared[name] = nil
+		if m != nil {
+			shared[name] = share(m)
 		}
-		colors.Set("bad", colorful.Hcl(40, 1.0, v).Clamped())
-		colors.Set("degraded", colorful.Hcl(90, 1.0, v).Clamped())
-		colors.Set("good", colorful.Hcl(120, 1.0, v).Clamped())
 	}
 
+	barista.SuppressSignals(true)
+	panic(barista.Run(configStatus(), reloadable(buildBar(shared), func(c config) interface{} {
+		return c.Layout
+	})))
+}
+
+// barModules builds the modules available to the layout, see layout.go. They
+// only start once they are streamed.
+func barModules() map[string]bar.Module {
 	localdate := clock.Local().
 		Output(time.Second, func(now time.Time) bar.Output {
 			return outputs.Pango(
-				pango.Icon("material-today").Alpha(0.6),
+				fontIcon("material-today").Alpha(0.6),
 				now.Format("Mon Jan 2"),
 			)
 		})
@@ -227,102 +319,10 @@ func main() {
 		Output(time.Second, func(now time.Time) bar.Output {
 			return outputs.Text(now.Format("15:04")).
 				OnClick(click.Left(func() {
-					mainModalController.Toggle("timezones")
+					toggleMode("timezones")
 				}))
 		})
 
-	makeTzClock := func(lbl, tzName string) bar.Module {
-		c, err := clock.ZoneByName(tzName)
-		if err != nil {
-			panic(err)
-		}
-		return c.Output(time.Minute, func(now time.Time) bar.Output {
-			return outputs.Pango(pango.Text(lbl).Smaller(), spacer, now.Format("15:04"))
-		})
-	}
-
-	var forges []bar.Module
-	for _, frg := range cfg.Forges {
-		notification := shell.New(homeDir+"/go/bin/forge", "notification", "list", "--host", frg.Host, "--unread", "-o", "json").
-			Output(func(s string) bar.Output {
-				if s == "" {
-					return nil
-				}
-
-				var parsed []forge.Notification
-				if err := json.Unmarshal([]byte(s), &parsed); err != nil {
-					return outputs.Text(s)
-				}
-
-				color := colors.Hex(colorOn)
-				if len(parsed) > 0 {
-					color = colors.Hex(colorOff)
-				}
-
-				// return outputs.Text(fmt.Sprintf("%d", len(parsed)))
-				return outputs.Pango(
-					pango.Icon(frg.Icon).Alpha(0.6),
-					spacer,
-					pango.Textf("%d", len(parsed)),
-				).Color(color).
-					OnClick(click.Left(func() {
-						_ = exec.Command("xdg-open", frg.OpenURL).Start()
-					}))
-			}).Every(time.Duration(5) * time.Minute)
-
-		forges = append(forges, notification)
-	}
-
-	openJiraAlerts := shell.New("/home/comario/bin/jira", "alert", "list", "--since", "5d", "--json").
-		Output(func(s string) bar.Output {
-			if s == "" {
-				return nil
-			}
-
-			var parsed []map[string]interface{}
-			if err := json.Unmarshal([]byte(s), &parsed); err != nil {
-				return outputs.Text(s)
-			}
-
-			color := colors.Hex(colorOn)
-			if len(parsed) > 0 {
-				color = colors.Hex(colorOff)
-			}
-
-			return outputs.Pango(
-				pango.Icon(cfg.Jira.Icon).Alpha(0.6),
-				spacer,
-				pango.Textf("%d", len(parsed)),
-			).Color(color).
-				OnClick(click.Left(func() {
-					_ = exec.Command("xdg-open", cfg.Jira.OpenURL).Start()
-				}))
-		}).Every(time.Duration(10) * time.Minute).WithEnv(fmt.Sprint("JIRA_API_TOKEN=" + cfg.Jira.Token))
-
-	zscallerStatus := shell.New("sh", "-c", "ip route show dev zcctun0 || echo __ZSCALER_ERROR__").
-		Output(func(s string) bar.Output {
-			if s == "" {
-				return nil
-			}
-
-			if strings.Contains(s, "__ZSCALER_ERROR__") {
-				return outputs.Pango(
-					pango.Icon("mdi-tunnel-outline").Alpha(0.6),
-				).Color(colors.Hex(colorOff))
-			}
-
-			length := len(strings.Split(s, "\n"))
-
-			color := colors.Hex(colorOn)
-			if length <= 3 {
-				color = colors.Hex(colorOff)
-			}
-
-			return outputs.Pango(
-				pango.Icon("mdi-tunnel-outline").Alpha(0.6),
-			).Color(color)
-		}).Every(time.Duration(2) * time.Minute)
-
 	battSummary, battDetail := split.New(battery.All().Output(func(i battery.Info) bar.Output {
 		if i.Status == battery.Disconnected || i.Status == battery.Unknown {
 			return nil
@@ -338,24 +338,24 @@ func main() {
 		case tenth < 10:
 			iconName += fmt.Sprintf("-%d0", tenth)
 		}
-		mainModalController.SetOutput("battery", makeIconOutput("mdi-"+iconName))
+		setModeOutput("battery", makeIconOutput("mdi-"+iconName))
 		rem := i.RemainingTime()
 		out := outputs.Group()
 		// First segment will be used in summary mode.
 		out.Append(outputs.Pango(
-			pango.Icon("mdi-"+iconName).Alpha(0.6),
+			fontIcon("mdi-"+iconName).Alpha(0.6),
 			pango.Textf("%d:%02d", int(rem.Hours()), int(rem.Minutes())%60),
 		).OnClick(click.Left(func() {
-			mainModalController.Toggle("battery")
+			toggleMode("battery")
 		})))
 		// Others in detail mode.
 		out.Append(outputs.Pango(
-			pango.Icon("mdi-"+iconName).Alpha(0.6),
+			fontIcon("mdi-"+iconName).Alpha(0.6),
 			pango.Textf("%d%%", i.RemainingPct()),
 			spacer,
 			pango.Textf("(%d:%02d)", int(rem.Hours()), int(rem.Minutes())%60),
 		).OnClick(click.Left(func() {
-			mainModalController.Toggle("battery")
+			toggleMode("battery")
 		})))
 		out.Append(outputs.Pango(
 			pango.Textf("%4.1f/%4.1f", i.EnergyNow, i.EnergyFull),
@@ -369,62 +369,65 @@ func main() {
 		case i.RemainingPct() <= 5:
 			out.Urgent(true)
 		case i.RemainingPct() <= 15:
-			out.Color(colors.Scheme("bad"))
+			out.Color(colorAlert)
 		case i.RemainingPct() <= 25:
-			out.Color(colors.Scheme("degraded"))
+			out.Color(colorWarn)
 		}
 		return out
 	}), 1)
 
-	wifiName, wifiDetails := split.New(wlan.Any().Output(func(i wlan.Info) bar.Output {
-		if !i.Connecting() && !i.Connected() {
-			mainModalController.SetOutput("network", makeIconOutput("mdi-ethernet"))
-			return nil
-		}
-		mainModalController.SetOutput("network", makeIconOutput("mdi-wifi"))
-		if i.Connecting() {
-			return outputs.Pango(pango.Icon("mdi-wifi").Alpha(0.6), "...").
-				Color(colors.Scheme("degraded"))
+	wifiName, wifiDetails := wifiStatus()
+
+	volSummary, volDetail := split.New(pulse.DefaultSink().Output(func(d pulse.Device) bar.Output {
+		// scroll to adjust, click to mute
+		onClick := func(e bar.Event) {
+			var err error
+			switch e.Button {
+			case bar.ButtonLeft:
+				err = d.ToggleMute()
+			case bar.ScrollUp:
+				err = d.AdjustVolume(5)
+			case bar.ScrollDown:
+				err = d.AdjustVolume(-5)
+			}
+			if err != nil {
+				l.Log("volume: %v", err)
+			}
 		}
-		out := outputs.Group()
-		// First segment shown in summary mode only.
-		out.Append(outputs.Pango(
-			pango.Icon("mdi-wifi").Alpha(0.6),
-			pango.Text(truncate(i.SSID, -9)),
-		).OnClick(click.Left(func() {
-			mainModalController.Toggle("network")
-		})))
-		// Full name, frequency, bssid in detail mode
-		out.Append(outputs.Pango(
-			pango.Icon("mdi-wifi").Alpha(0.6),
-			pango.Text(i.SSID),
-		))
-		out.Append(outputs.Textf("%2.1fG", i.Frequency.Gigahertz()))
-		out.Append(outputs.Pango(
-			pango.Icon("mdi-access-point").Alpha(0.8),
-			pango.Text(i.AccessPointMAC).Small(),
-		))
-		return out
-	}), 1)
+		sink := outputs.Pango(fontIcon("mdi-speaker").Alpha(0.6), spacer, pango.Text(d.Description).Small()).
+			OnClick(onClick)
 
-	vol := volume.New(alsa.DefaultMixer()).Output(func(v volume.Volume) bar.Output {
-		if v.Mute {
-			return outputs.
-				Pango(pango.Icon("mdi-volume-mute").Alpha(0.8), spacer, "MUT").
-				Color(colors.Scheme("degraded"))
+		if d.Mute {
+			return outputs.Group(outputs.
+				Pango(fontIcon("mdi-volume-mute").Alpha(0.8), spacer, "MUT").
+				Color(colorWarn), sink).OnClick(onClick)
 		}
 		iconName := "off"
-		pct := v.Pct()
-		if pct > 66 {
+		if d.Volume > 66 {
 			iconName = "high"
-		} else if pct > 33 {
+		} else if d.Volume > 33 {
 			iconName = "low"
 		}
-		return outputs.Pango(
-			pango.Icon("mdi-volume-"+iconName).Alpha(0.6),
+		return outputs.Group(outputs.Pango(
+			fontIcon("mdi-volume-"+iconName).Alpha(0.6),
 			spacer,
-			pango.Textf("%2d%%", pct),
-		)
+			pango.Textf("%2d%%", d.Volume),
+		), sink).OnClick(onClick)
+	}), 1)
+
+	mic := pulse.DefaultSource().Output(func(d pulse.Device) bar.Output {
+		toggle := click.Left(func() {
+			if err := d.ToggleMute(); err != nil {
+				l.Log("microphone: %v", err)
+			}
+		})
+		if d.Mute {
+			return outputs.Pango(fontIcon("mdi-microphone-off").Alpha(0.6)).
+				Color(colorMuted).OnClick(toggle)
+		}
+		out := outputs.Pango(fontIcon("mdi-microphone").Alpha(0.8)).OnClick(toggle)
+		// the mic is hot while something records from it
+		return threshold(out, d.Streams > 0, false, false, true)
 	})
 
 	// System information modules
@@ -435,32 +438,23 @@ func main() {
 	swapMem := swapInfo()
 	temp := cpuTemp()
 
-	sub := netlink.Any()
-	iface := sub.Get().Name
-	sub.Unsubscribe()
-	netsp := netspeed.New(iface).
+	netsp := routespeed.New().
 		RefreshInterval(2 * time.Second).
-		Output(func(s netspeed.Speeds) bar.Output {
+		Output(func(s routespeed.Speeds) bar.Output {
+			if s.Iface == "" {
+				return nil
+			}
 			return outputs.Pango(
-				pango.Icon("mdi-upload-network").Alpha(0.5), spacer, pango.Textf("%7s", format.Byterate(s.Tx)),
+				pango.Text(s.Iface).Small(), spacer,
+				fontIcon("mdi-upload-network").Alpha(0.5), spacer, pango.Textf("%7s", format.Byterate(s.Tx)),
 				pango.Text(" ").Small(),
-				pango.Icon("mdi-download-network").Alpha(0.5), spacer, pango.Textf("%7s", format.Byterate(s.Rx)),
+				fontIcon("mdi-download-network").Alpha(0.5), spacer, pango.Textf("%7s", format.Byterate(s.Rx)),
 			)
 		})
 
-	net := netinfo.New().Output(func(i netinfo.State) bar.Output {
-		if !i.Enabled() {
-			return nil
-		}
-		if i.Connecting() || len(i.IPs) < 1 {
-			return outputs.Text(i.Name).Color(colors.Scheme("degraded"))
-		}
-		return outputs.Group(outputs.Text(i.Name), outputs.Textf("%s", i.IPs[0]))
-	})
-
 	formatDiskSpace := func(i diskspace.Info, icon string) bar.Output {
 		out := outputs.Pango(
-			pango.Icon(icon).Alpha(0.7), spacer, format.IBytesize(i.Available))
+			fontIcon(icon).Alpha(0.7), spacer, format.IBytesize(i.Available))
 		return threshold(out,
 			i.Available.Gigabytes() < 1,
 			i.AvailFrac() < 0.05,
@@ -481,29 +475,16 @@ func main() {
 
 	mainDiskio := diskio.New(strings.TrimPrefix(rootDev, "/dev/")).
 		Output(func(r diskio.IO) bar.Output {
-			return pango.Icon("mdi-swap-vertical").
+			return fontIcon("mdi-swap-vertical").
 				Concat(spacer).
 				ConcatText(format.IByterate(r.Total()))
 		})
 
-	mediaSummary, mediaDetail := split.New(media.New("spotify").Output(mediaFormatFunc), 1)
-
-	mainModal := modal.New()
-
-	sysMode := mainModal.Mode("sysinfo").
-		SetOutput(makeIconOutput("mdi-poll")).
-		Add(loadAvg).
-		Detail(loadAvgDetail, uptime).
-		Add(freeMem).
-		Detail(swapMem, temp)
-	if homeDiskspace != nil {
-		sysMode.Detail(homeDiskspace)
-	}
-	sysMode.Detail(rootDiskspace, mainDiskio)
-
-	mainModal.Mode("gitlab notifications").
-		SetOutput(makeIconOutput("mdi-alert")).
-		Add(forges...).Add(openJiraAlerts)
+	followActivePlayer(mediaPlayer, "spotify")
+	// LoopStatus changes don't update the media module
+	onSet(&mprisLoop, func() { mediaPlayer.Output(mediaFormatFunc) })
+	onThemeChange(func() { mediaPlayer.Output(mediaFormatFunc) })
+	mediaSummary, mediaDetail := split.New(mediaPlayer.Output(mediaFormatFunc), 1)
 
 	// TODO:
 	// bavarianbidi: read bluetooth devices from config file instead of hardcoding them here
@@ -521,51 +502,46 @@ func main() {
 	// bluetooth box
 	soundcoreSummary, soundcoreDetail := bluetoothAudio("hci0", "08:EB:ED:83:82:01", "speaker")
 
-	mainModal.Mode("bluetooth-audio").
-		SetOutput(makeIconOutput("mdi-bluetooth")).
-		Add(soundcoreSummary).
-		Add(headsetSummary).
-		Detail(soundcoreDetail).
-		Detail(headsetDetail)
-
 	quickMillSummary, quickMillDetail := shellyStatus("192.168.178.64", "coffee")
 
-	mainModal.Mode("shelly").
-		SetOutput(makeIconOutput("mdi-coffee")).
-		Add(quickMillSummary).
-		Detail(quickMillDetail)
-
-	mainModal.Mode("VPN").
-		SetOutput(makeIconOutput("mdi-tunnel-outline")).
-		// Summary().
-		// Detail().
-		Add(zscallerStatus)
-
-	mainModal.Mode("network").
-		SetOutput(makeIconOutput("mdi-ethernet")).
-		Summary(wifiName).
-		Detail(wifiDetails, net, netsp)
-
-	mainModal.Mode("media").
-		SetOutput(makeIconOutput("mdi-music-box")).
-		Add(vol, mediaSummary, mediaDetail)
-
-	mainModal.Mode("battery").
-		// Filled in by the battery module if one is available.
-		SetOutput(nil).
-		Summary(battSummary).
-		Detail(battDetail)
-
-	mainModal.Mode("timezones").
-		SetOutput(makeIconOutput("mdi-earth")).
-		Detail(makeTzClock("Seattle", "America/Los_Angeles")).
-		Detail(makeTzClock("New York", "America/New_York")).
-		Detail(makeTzClock("UTC", "Etc/UTC")).
-		Detail(makeTzClock("Berlin", "Europe/Berlin")).
-		Detail(makeTzClock("Bangalore", "Asia/Kolkata"))
-
-	var mm bar.Module
-	mm, mainModalController = mainModal.Build()
-	barista.SuppressSignals(true)
-	panic(barista.Run(mm, localdate, localtime))
+	return map[string]bar.Module{
+		"loadAvg":       loadAvg,
+		"loadAvgDetail": loadAvgDetail,
+		"uptime":        uptime,
+		"freeMem":       freeMem,
+		"swap":          swapMem,
+		"cpuTemp":       temp,
+		"homeDisk":      homeDiskspace,
+		"rootDisk":      rootDiskspace,
+		"diskio":        mainDiskio,
+		"notifications": reloadable(notifications, func(c config) interface{} {
+			return []interface{}{c.Forges, c.Jira}
+		}),
+		"headset":         headsetSummary,
+		"headsetDetail":   headsetDetail,
+		"soundcore":       soundcoreSummary,
+		"soundcoreDetail": soundcoreDetail,
+		"quickMill":       quickMillSummary,
+		"quickMillDetail": quickMillDetail,
+		"vpn":             reloadable(tunnels, func(c config) interface{} { return c.Tunnels }),
+		"wireguard":       reloadable(wireguardTunnels, func(c config) interface{} { return c.Tunnels }),
+		"wifi":            wifiName,
+		"wifiDetail":      wifiDetails,
+		"netinfo":         interfacesDetail(),
+		"netspeed":        netsp,
+		"probes":          reloadable(connectivityProbes, func(c config) interface{} { return c.Probes }),
+		"volume":          volSummary,
+		"volumeDetail":    volDetail,
+		"microphone":      mic,
+		"audioOutputs":    audioOutputs(),
+		"media":           mediaSummary,
+		"mediaDetail":     mediaDetail,
+		"battery":         battSummary,
+		"batteryDetail":   battDetail,
+		"timezones": reloadable(timezoneClocks, func(c config) interface{} {
+			return c.Timezones
+		}),
+		"localdate": localdate,
+		"localtime": localtime,
+	}
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/barista-run/barista/timing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"日本語", 6},
+		{"\uff41\uff42", 4},               // fullwidth latin
+		{"e\u0301", 1},                    // combining acute accent
+		{"\u2764\ufe0f", 1},               // variation selector
+		{"\U0001f469\u200d\U0001f4bb", 4}, // emoji joined by a zero width joiner
+		{"Tōkyō 東京", 10},
+	}
+	for _, tc := range tests {
+		if got := displayWidth(tc.in); got != tc.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		l    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 6, "hello⋯"},
+		{"hello world", -6, "⋯world"},
+		{"日本語テキスト", 14, "日本語テキスト"},
+		{"日本語テキスト", 6, "日本⋯"},
+		{"日本語テキスト", 7, "日本語⋯"},
+		{"日本語テキスト", -6, "⋯スト"},
+		{"ab日本", 4, "ab⋯"},
+		// narrower than one wide rune, only the ellipsis is left
+		{"日本語", 2, "⋯"},
+		{"日本語", 1, "⋯"},
+		{"日本語", -2, "⋯"},
+	}
+	for _, tc := range tests {
+		got := truncate(tc.in, tc.l)
+		if got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.l, got, tc.want)
+		}
+		if w, limit := displayWidth(got), max(tc.l, -tc.l); w > limit {
+			t.Errorf("truncate(%q, %d) is %d columns wide", tc.in, tc.l, w)
+		}
+	}
+}
+
+func TestMarquee(t *testing.T) {
+	defer timing.ExitTestMode()
+
+	// the test clock starts at a multiple of 10 seconds, so with the three
+	// spaces appended, seven character texts start scrolling at offset 0
+	tests := []struct {
+		name string
+		text string
+		cols int
+		want []string
+	}{
+		{"fits", "hello", 5, []string{"hello", "hello"}},
+		{"ascii", "abcdefg", 4, []string{"abcd", "bcde", "cdef", "defg", "efg ", "fg  ", "g   ", "   a"}},
+		{"cjk", "日本語テキスト", 5, []string{"日本 ", "本語 ", "語テ ", "テキ ", "キス ", "スト ", "ト   "}},
+		{"narrower than a wide rune", "日本語テキスト", 1, []string{" ", " "}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			timing.TestMode()
+			out := marquee(tc.text, tc.cols, time.Second)
+			for i, want := range tc.want {
+				segments := out.Segments()
+				if len(segments) != 1 {
+					t.Fatalf("step %d: got %d segments, want 1", i, len(segments))
+				}
+				if got, _ := segments[0].Content(); got != want {
+					t.Errorf("step %d: marquee(%q, %d) = %q, want %q", i, tc.text, tc.cols, got, want)
+				}
+				timing.AdvanceBy(time.Second)
+			}
+		})
+	}
+}
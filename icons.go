@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/barista-run/barista/pango"
+	"github.com/barista-run/barista/pango/icons/fontawesome"
+	"github.com/barista-run/barista/pango/icons/material"
+	"github.com/barista-run/barista/pango/icons/mdi"
+	"github.com/barista-run/barista/pango/icons/typicons"
+)
+
+// e.g.:
+// icons:
+//
+//	mdi: ~/src/MaterialDesign-Webfont
+//	material: ~/src/material-design-icons
+//	typicons: ~/src/typicons.font
+//	fontawesome: ~/src/Font-Awesome
+//
+// Paths point to a checkout of the icon font repository. Icon sets without a
+// path are loaded from the default location below ~/go/src, fontawesome is
+// only loaded if configured.
+type iconsConfig struct {
+	MDI         string `koanf:"mdi"`
+	Material    string `koanf:"material"`
+	Typicons    string `koanf:"typicons"`
+	FontAwesome string `koanf:"fontawesome"`
+}
+
+func defaultIcons() iconsConfig {
+	return iconsConfig{
+		MDI:      "~/go/src/github.com/Templarian/MaterialDesign-Webfont",
+		Material: "~/go/src/github.com/google/material-design-icons",
+		Typicons: "~/go/src/github.com/stephenhutchings/typicons.font",
+	}
+}
+
+// loadedIconSets holds the icon name prefixes of all successfully loaded
+// icon fonts.
+var loadedIconSets = map[string]bool{}
+
+// loadIcons loads all configured icon fonts. Icon sets that fail to load are
+// reported in the returned error; their icons fall back to text labels.
+func loadIcons(cfg iconsConfig) error {
+	defaults := defaultIcons()
+	sets := []struct {
+		path     string
+		fallback string
+		load     func(string) error
+		prefixes []string
+	}{
+		{cfg.MDI, defaults.MDI, mdi.Load, []string{"mdi"}},
+		{cfg.Material, defaults.Material, material.Load, []string{"material"}},
+		{cfg.Typicons, defaults.Typicons, typicons.Load, []string{"typecn"}},
+		{cfg.FontAwesome, defaults.FontAwesome, fontawesome.Load, []string{"fa", "fab", "far"}},
+	}
+
+	var errs []error
+	for _, set := range sets {
+		path := set.path
+		if path == "" {
+			path = set.fallback
+		}
+		if path == "" {
+			continue
+		}
+		if err := set.load(expandHome(path)); err != nil {
+			errs = append(errs, fmt.Errorf("icons %s: %w", set.prefixes[0], err))
+			continue
+		}
+		for _, prefix := range set.prefixes {
+			loadedIconSets[prefix] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fontIcon returns the named icon, or a text label derived from its name if the
+// icon isn't available, e.g. "battery" for "mdi-battery-charging-50".
+func fontIcon(name string) *pango.Node {
+	if n := pango.Icon(name); n.String() != "" {
+		return n
+	}
+	_, label, _ := strings.Cut(name, "-")
+	label, _, _ = strings.Cut(label, "-")
+	return pango.Text(label).Small()
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return home(path[2:])
+	}
+	return filepath.Clean(path)
+}
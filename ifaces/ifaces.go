@@ -0,0 +1,197 @@
+// Package ifaces provides a module listing all network interfaces that are up
+// with their addresses, default gateway and DNS servers, including bridges
+// like docker0. veth pairs and bridges without addresses are left out.
+package ifaces
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/base/watchers/netlink"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	vnl "github.com/vishvananda/netlink"
+)
+
+// Interface is a network interface that is up.
+type Interface struct {
+	Name string
+	// IPs are sorted by preference, IPv4 first.
+	IPs []net.IP
+	// Gateway is nil without a default route through the interface.
+	Gateway net.IP
+	// DNS are the interface's DNS servers as known to systemd-resolved.
+	DNS []string
+}
+
+// State holds all interfaces that are up, and the DNS servers not bound to
+// an interface, e.g. from /etc/resolv.conf.
+type State struct {
+	Interfaces []Interface
+	DNS        []string
+}
+
+// skippedTypes are the link types left out of the state.
+var skippedTypes = map[string]bool{
+	"veth": true,
+}
+
+// settleTime is how long link changes are collected before the state, which
+// runs resolvectl, is read again. Links tend to change in bursts.
+const settleTime = 500 * time.Millisecond
+
+// Module watches all network interfaces.
+type Module struct {
+	outputFunc value.Value
+}
+
+// New creates a module for all interfaces.
+func New() *Module {
+	m := &Module{}
+	l.Register(m, "outputFunc")
+
+	m.Output(func(s State) bar.Output {
+		out := outputs.Group()
+		for _, i := range s.Interfaces {
+			out.Append(outputs.Textf("%s: %d addresses", i.Name, len(i.IPs)))
+		}
+		return out
+	})
+
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(State) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	links := netlink.All()
+
+	outputFunc := m.outputFunc.Get().(func(State) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+	defer done()
+
+	next := links.Next()
+	state := getState(links.Get())
+	s.Output(outputFunc(state))
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-next:
+			next = links.Next()
+			if settle == nil {
+				settle = time.After(settleTime)
+			}
+			continue
+		case <-settle:
+			settle = nil
+			state = getState(links.Get())
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(State) bar.Output)
+		}
+		s.Output(outputFunc(state))
+	}
+}
+
+func getState(links []netlink.Link) State {
+	var state State
+	gateways := defaultGateways()
+	dns, global := dnsServers()
+	for _, link := range links {
+		if link.Name == "lo" || link.State != netlink.Up && link.State != netlink.Unknown {
+			continue
+		}
+		if vl, err := vnl.LinkByName(link.Name); err == nil {
+			if skippedTypes[vl.Type()] || vl.Type() == "bridge" && len(link.IPs) == 0 {
+				continue
+			}
+		}
+		state.Interfaces = append(state.Interfaces, Interface{
+			Name:    link.Name,
+			IPs:     link.IPs,
+			Gateway: gateways[link.Name],
+			DNS:     dns[link.Name],
+		})
+	}
+	state.DNS = global
+	return state
+}
+
+// defaultGateways returns the default route gateways by interface name.
+func defaultGateways() map[string]net.IP {
+	gateways := map[string]net.IP{}
+	routes, err := vnl.RouteListFiltered(vnl.FAMILY_ALL,
+		&vnl.Route{Table: syscall.RT_TABLE_MAIN}, vnl.RT_FILTER_TABLE)
+	if err != nil {
+		return gateways
+	}
+	for _, r := range routes {
+		if r.Dst != nil || r.Gw == nil {
+			continue
+		}
+		link, err := vnl.LinkByIndex(r.LinkIndex)
+		if err != nil {
+			continue
+		}
+		if _, ok := gateways[link.Attrs().Name]; !ok {
+			gateways[link.Attrs().Name] = r.Gw
+		}
+	}
+	return gateways
+}
+
+// resolvectlLink matches e.g. "Link 3 (wlan0): 192.168.1.1 fd00::1".
+var resolvectlLink = regexp.MustCompile(`^Link \d+ \(([^)]+)\):(.*)$`)
+
+// dnsServers returns the DNS servers per interface and the global ones from
+// `resolvectl dns`, or from /etc/resolv.conf without systemd-resolved.
+func dnsServers() (perLink map[string][]string, global []string) {
+	perLink = map[string][]string{}
+	out, err := exec.Command("resolvectl", "dns").Output()
+	if err != nil {
+		return perLink, resolvConfServers()
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if match := resolvectlLink.FindStringSubmatch(line); match != nil {
+			if servers := strings.Fields(match[2]); len(servers) > 0 {
+				perLink[match[1]] = servers
+			}
+			continue
+		}
+		if servers, ok := strings.CutPrefix(line, "Global:"); ok {
+			global = strings.Fields(servers)
+		}
+	}
+	return perLink, global
+}
+
+func resolvConfServers() []string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
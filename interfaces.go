@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/ifaces"
+)
+
+// clipboardCommand returns the command copying its stdin to the clipboard,
+// wl-copy on Wayland and xclip otherwise.
+func clipboardCommand() []string {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return []string{"wl-copy"}
+	}
+	return []string{"xclip", "-selection", "clipboard"}
+}
+
+func copyToClipboard(text string) {
+	args := clipboardCommand()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
+}
+
+// interfaceIcon guesses the kind of interface from its name.
+func interfaceIcon(name string) string {
+	switch {
+	case strings.HasPrefix(name, "wl"):
+		return "mdi-wifi"
+	case strings.HasPrefix(name, "tun"), strings.HasPrefix(name, "wg"),
+		strings.HasPrefix(name, "zcc"), strings.HasPrefix(name, "tailscale"):
+		return "mdi-tunnel-outline"
+	case strings.HasPrefix(name, "docker"), strings.HasPrefix(name, "br-"),
+		strings.HasPrefix(name, "virbr"):
+		return "mdi-docker"
+	default:
+		return "mdi-ethernet"
+	}
+}
+
+// copyable returns a segment for s that copies it to the clipboard on click.
+func copyable(s string, nodes ...interface{}) *bar.Segment {
+	return outputs.Pango(append(nodes, s)...).
+		OnClick(click.Left(func() { copyToClipboard(s) }))
+}
+
+func interfacesDetail() bar.Module {
+	return ifaces.New().Output(func(s ifaces.State) bar.Output {
+		out := outputs.Group()
+		for _, i := range s.Interfaces {
+			if len(i.IPs) == 0 {
+				out.Append(outputs.Pango(
+					fontIcon(interfaceIcon(i.Name)).Alpha(0.6), spacer,
+					pango.Text(i.Name),
+				).Color(colorWarn))
+				continue
+			}
+			out.Append(outputs.Pango(
+				fontIcon(interfaceIcon(i.Name)).Alpha(0.6), spacer,
+				pango.Text(i.Name),
+			))
+			for _, ip := range i.IPs {
+				if ip.IsLinkLocalUnicast() {
+					out.Append(copyable(ip.String()).Color(colorMuted))
+					continue
+				}
+				out.Append(copyable(ip.String()))
+			}
+			if i.Gateway != nil {
+				out.Append(copyable(i.Gateway.String(),
+					fontIcon("mdi-router-network").Alpha(0.6), spacer))
+			}
+			for _, dns := range i.DNS {
+				out.Append(copyable(dns, pango.Text("dns").Small(), spacer))
+			}
+		}
+		for _, dns := range s.DNS {
+			if ip := net.ParseIP(dns); ip != nil && ip.IsLoopback() {
+				// the local stub resolver isn't worth copying
+				continue
+			}
+			out.Append(copyable(dns, pango.Text("dns").Small(), spacer))
+		}
+		return out
+	})
+}
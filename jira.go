@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/jira"
+)
+
+// e.g.:
+// jira:
+//
+//	queries:
+//	- name: my open bugs
+//	  jql: assignee = currentUser() AND type = Bug AND resolution = Unresolved
+//	  icon: mdi-bug
+//	  warn: 1
+//	  alert: 5
+type jiraQueryConfig struct {
+	Name    string `koanf:"name"`
+	JQL     string `koanf:"jql"`
+	Icon    string `koanf:"icon"`
+	OpenURL string `koanf:"openURL"`
+	// Warn and Alert are the issue counts from which on the segment turns
+	// warn/alert colored. Without any thresholds every match is an alert.
+	Warn  int `koanf:"warn"`
+	Alert int `koanf:"alert"`
+}
+
+func jiraQuery(client *jira.Client, cfg jiraQueryConfig, done <-chan struct{}) bar.Module {
+	openURL := cfg.OpenURL
+	if openURL == "" {
+		openURL = client.BrowseURL(cfg.JQL)
+	}
+	alert := cfg.Alert
+	if alert == 0 && cfg.Warn == 0 {
+		alert = 1
+	}
+
+	// queries without an icon are labeled by their name
+	label := fontIcon(cfg.Icon).Alpha(0.6)
+	if cfg.Icon == "" {
+		label = pango.Text(cfg.Name).Smaller()
+	}
+
+	return jira.New(client, cfg.JQL).Until(done).
+		Output(func(q jira.QueryState) bar.Output {
+
+			if !q.Reachable() {
+				return outputs.Pango(
+					label,
+					spacer,
+					pango.Text("?"),
+				).Color(colorAlert)
+			}
+
+			color := colorOk
+			switch {
+			case alert > 0 && q.Total >= alert:
+				color = colorAlert
+			case cfg.Warn > 0 && q.Total >= cfg.Warn:
+				color = colorWarn
+			}
+
+			return outputs.Pango(
+				label,
+				spacer,
+				pango.Textf("%d", q.Total),
+			).Color(color).
+				OnClick(click.Left(func() {
+					_ = exec.Command("xdg-open", openURL).Start()
+				}))
+		})
+}
+
+const jiraActiveIssueJQL = `assignee = currentUser() AND status = "In Progress" ORDER BY updated DESC`
+
+// workTimer accumulates time spent on an issue until it is logged to Jira.
+type workTimer struct {
+	mu      sync.Mutex
+	issue   string
+	first   time.Time // first start, used as worklog start time
+	started time.Time // zero while paused
+	elapsed time.Duration
+	logging bool // a worklog request is in flight
+	err     error
+}
+
+func (t *workTimer) toggle(issue string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if !t.started.IsZero() {
+		t.elapsed += now.Sub(t.started)
+		t.started = time.Time{}
+		return
+	}
+	if t.issue != issue {
+		if t.elapsed > 0 {
+			// refuse to drop unlogged time, it has to be logged first
+			t.err = fmt.Errorf("%s has unlogged time", t.issue)
+			return
+		}
+		t.issue, t.first = issue, now
+	}
+	if t.first.IsZero() {
+		t.first = now
+	}
+	t.started = now
+	t.err = nil
+}
+
+func (t *workTimer) status() (issue string, running bool, elapsed time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed = t.elapsed
+	if !t.started.IsZero() {
+		elapsed += time.Since(t.started)
+	}
+	return t.issue, !t.started.IsZero(), elapsed, t.err
+}
+
+// log books the accumulated time and resets the timer. On failure the time is
+// kept so that logging can be retried. The request is sent without holding the
+// lock so that rendering is not blocked by a slow Jira.
+func (t *workTimer) log(client *jira.Client) {
+	t.mu.Lock()
+	issue, first, elapsed := t.issue, t.first, t.elapsed
+	if !t.started.IsZero() {
+		elapsed += time.Since(t.started)
+	}
+	if t.logging || issue == "" || elapsed == 0 {
+		t.mu.Unlock()
+		return
+	}
+	t.logging = true
+	t.mu.Unlock()
+
+	err := client.AddWorklog(issue, first, elapsed)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logging, t.err = false, err
+	if err != nil {
+		return
+	}
+	t.issue, t.first, t.started, t.elapsed = "", time.Time{}, time.Time{}, 0
+}
+
+// jiraTimer is shared by all jiraActiveIssue modules. Config reloads rebuild
+// the module, the tracked time has to survive that.
+var jiraTimer = &workTimer{}
+
+func formatWorkTime(d time.Duration) string {
+	h, m, s := hms(d)
+	return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+}
+
+// jiraActiveIssue shows the issue currently in progress. A left click starts
+// and stops the work timer, a right click logs the tracked time to the issue.
+func jiraActiveIssue(client *jira.Client, icon string, done <-chan struct{}) bar.Module {
+	timer := jiraTimer
+	module := jira.New(client, jiraActiveIssueJQL).RefreshInterval(5 * time.Minute).Until(done)
+
+	return module.Output(func(q jira.QueryState) bar.Output {
+		if !q.Reachable() {
+			return outputs.Pango(
+				fontIcon(icon).Alpha(0.6),
+				spacer,
+				pango.Text("?"),
+			).Color(colorAlert)
+		}
+		if len(q.Issues) == 0 {
+			return nil
+		}
+		issue := q.Issues[0]
+
+		onClick := func(e bar.Event) {
+			switch e.Button {
+			case bar.ButtonLeft:
+				timer.toggle(issue.Key)
+			case bar.ButtonRight:
+				timer.log(client)
+			default:
+				return
+			}
+			module.Refresh()
+		}
+
+		format := func() bar.Output {
+			timerIssue, running, elapsed, err := timer.status()
+
+			nodes := []interface{}{
+				fontIcon(icon).Alpha(0.6),
+				spacer,
+				pango.Text(issue.Key),
+				spacer,
+				pango.Text(truncate(issue.Summary, 30)).Small(),
+			}
+			switch {
+			case timerIssue == issue.Key && elapsed > 0:
+				nodes = append(nodes, spacer, pango.Text(formatWorkTime(elapsed)))
+			case timerIssue != "" && elapsed > 0:
+				// unlogged time of a previous issue, a right click logs it
+				nodes = append(nodes, spacer,
+					pango.Textf("%s %s", timerIssue, formatWorkTime(elapsed)).Small())
+			}
+
+			color := colorOk
+			switch {
+			case err != nil:
+				color = colorAlert
+			case !running && elapsed > 0:
+				color = colorWarn
+			}
+			return outputs.Pango(nodes...).Color(color).OnClick(onClick)
+		}
+
+		if _, running, _, _ := timer.status(); running {
+			return outputs.Repeat(func(time.Time) bar.Output {
+				return format()
+			}).Every(time.Second)
+		}
+		return format()
+	})
+}
@@ -0,0 +1,193 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// endpoint: https://jira.example.com/rest/api/2/search/jql?jql=...
+// The legacy /rest/api/2/search endpoint reports total, the enhanced
+// /search/jql one pages with nextPageToken instead.
+type searchResponse struct {
+	Total         *int   `json:"total"`
+	NextPageToken string `json:"nextPageToken"`
+	Issues        []struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary string `json:"summary"`
+			Status  struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	} `json:"issues"`
+}
+
+// endpoint: https://jira.example.com/rest/api/3/search/approximate-count
+type countRequest struct {
+	JQL string `json:"jql"`
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+// endpoint: https://jira.example.com/rest/api/2/issue/KEY-1/worklog
+type worklogRequest struct {
+	Started          string `json:"started"`
+	TimeSpentSeconds int    `json:"timeSpentSeconds"`
+}
+
+const (
+	// DefaultSearchPath is the enhanced JQL search endpoint. Jira Server and
+	// Data Center only provide the legacy "/rest/api/2/search".
+	DefaultSearchPath string = "/rest/api/2/search/jql"
+
+	jiraCount   string = "/rest/api/3/search/approximate-count"
+	jiraWorklog string = "/rest/api/2/issue/%s/worklog"
+
+	// jiraTimeFormat is the timestamp layout expected by the worklog API.
+	jiraTimeFormat string = "2006-01-02T15:04:05.000-0700"
+)
+
+type Issue struct {
+	Key     string
+	Summary string
+	Status  string
+}
+
+// Client talks to the Jira REST API. If User is set, requests use basic auth
+// (Jira Cloud API tokens), otherwise the token is sent as a bearer token
+// (personal access tokens on Jira Server/Data Center).
+type Client struct {
+	URL   string
+	User  string
+	Token string
+
+	// SearchPath is the search endpoint, DefaultSearchPath unless set.
+	SearchPath string
+
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, user, token string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(baseURL, "/"),
+		User:       user,
+		Token:      token,
+		SearchPath: DefaultSearchPath,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// BrowseURL returns the web UI link listing all issues matching jql.
+func (c *Client) BrowseURL(jql string) string {
+	return c.URL + "/issues/?jql=" + url.QueryEscape(jql)
+}
+
+// Search runs jql and returns the total number of matches together with the
+// first maxResults issues. If the endpoint does not report a total and there
+// are more pages, the approximate count of matches is asked for instead.
+func (c *Client) Search(jql string, maxResults int) (int, []Issue, error) {
+	response, err := c.search(jql, maxResults)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	issues := make([]Issue, 0, len(response.Issues))
+	for _, i := range response.Issues {
+		issues = append(issues, Issue{
+			Key:     i.Key,
+			Summary: i.Fields.Summary,
+			Status:  i.Fields.Status.Name,
+		})
+	}
+	if response.Total != nil {
+		return *response.Total, issues, nil
+	}
+
+	if response.NextPageToken == "" {
+		return len(issues), issues, nil
+	}
+
+	body, err := json.Marshal(countRequest{JQL: jql})
+	if err != nil {
+		return 0, nil, err
+	}
+	var count countResponse
+	if err := c.do(http.MethodPost, jiraCount, bytes.NewReader(body), &count); err != nil {
+		return 0, nil, err
+	}
+	return count.Count, issues, nil
+}
+
+func (c *Client) search(jql string, maxResults int) (*searchResponse, error) {
+	params := url.Values{}
+	params.Set("jql", jql)
+	params.Set("maxResults", fmt.Sprint(maxResults))
+	params.Set("fields", "summary,status")
+
+	path := c.SearchPath
+	if path == "" {
+		path = DefaultSearchPath
+	}
+	var response searchResponse
+	if err := c.do(http.MethodGet, path+"?"+params.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AddWorklog books d on the issue, starting at started. Jira only accepts
+// whole minutes, so d is rounded and at least one minute is logged.
+func (c *Client) AddWorklog(key string, started time.Time, d time.Duration) error {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	body, err := json.Marshal(worklogRequest{
+		Started:          started.Format(jiraTimeFormat),
+		TimeSpentSeconds: minutes * 60,
+	})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf(jiraWorklog, url.PathEscape(key))
+	return c.do(http.MethodPost, path, bytes.NewReader(body), nil)
+}
+
+func (c *Client) do(method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, c.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("jira: %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,117 @@
+package jira
+
+import (
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/notifier"
+	"github.com/barista-run/barista/base/value"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/timing"
+)
+
+type QueryState struct {
+	JQL    string
+	Total  int
+	Issues []Issue
+	Err    error
+}
+
+func (q QueryState) Reachable() bool {
+	return q.Err == nil
+}
+
+// Module periodically runs a JQL query and renders the result.
+type Module struct {
+	client     *Client
+	jql        string
+	maxResults int
+	done       <-chan struct{}
+	scheduler  *timing.Scheduler
+	outputFunc value.Value
+	notifyCh   <-chan struct{}
+	notifyFn   func()
+}
+
+func New(client *Client, jql string) *Module {
+
+	m := &Module{
+		client:     client,
+		jql:        jql,
+		maxResults: 1,
+		scheduler:  timing.NewScheduler(),
+	}
+	m.notifyFn, m.notifyCh = notifier.New()
+	l.Label(m, jql)
+	l.Register(m, "outputFunc")
+	m.RefreshInterval(10 * time.Minute)
+
+	m.Output(func(q QueryState) bar.Output {
+		if !q.Reachable() {
+			return nil
+		}
+		return outputs.Textf("%d", q.Total)
+	})
+
+	return m
+}
+
+func (m *Module) Output(outputFunc func(QueryState) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+func (m *Module) RefreshInterval(interval time.Duration) *Module {
+	m.scheduler.Every(interval)
+	return m
+}
+
+// MaxResults sets how many issues are fetched along with the total count.
+func (m *Module) MaxResults(n int) *Module {
+	m.maxResults = n
+	return m
+}
+
+// Refresh runs the query again and updates the output.
+func (m *Module) Refresh() {
+	m.notifyFn()
+}
+
+// Until ends Stream once done is closed, e.g. when the module is replaced
+// after a config change.
+func (m *Module) Until(done <-chan struct{}) *Module {
+	m.done = done
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	state := m.query()
+
+	outputFunc := m.outputFunc.Get().(func(QueryState) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+
+	defer done()
+	defer m.scheduler.Close()
+
+	for {
+		s.Output(outputFunc(state))
+		select {
+		case <-m.scheduler.C:
+			state = m.query()
+		case <-m.notifyCh:
+			state = m.query()
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(QueryState) bar.Output)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *Module) query() QueryState {
+	state := QueryState{JQL: m.jql}
+	state.Total, state.Issues, state.Err = m.client.Search(m.jql, m.maxResults)
+	return state
+}
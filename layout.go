@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/group/modal"
+)
+
+// e.g.:
+// layout:
+//
+//	modes:
+//	- name: sysinfo
+//	  icon: mdi-poll
+//	  modules:
+//	  - add: loadAvg
+//	  - detail: [loadAvgDetail, uptime]
+//	right: [localdate, localtime]
+//
+// Modules are added to a mode in the order they are listed. Modes without an
+// icon stay hidden until one of their modules sets it, like the battery.
+type layoutConfig struct {
+	Modes []modeConfig `koanf:"modes"`
+	Right []string     `koanf:"right"`
+}
+
+type modeConfig struct {
+	Name    string            `koanf:"name"`
+	Icon    string            `koanf:"icon"`
+	Modules []modeModuleGroup `koanf:"modules"`
+}
+
+type modeModuleGroup struct {
+	Add     []string `koanf:"add"`
+	Summary []string `koanf:"summary"`
+	Detail  []string `koanf:"detail"`
+}
+
+func defaultLayout() layoutConfig {
+	return layoutConfig{
+		Modes: []modeConfig{
+			{Name: "sysinfo", Icon: "mdi-poll", Modules: []modeModuleGroup{
+				{Add: []string{"loadAvg"}},
+				{Detail: []string{"loadAvgDetail", "uptime"}},
+				{Add: []string{"freeMem"}},
+				{Detail: []string{"swap", "cpuTemp", "homeDisk", "rootDisk", "diskio"}},
+			}},
+			{Name: "gitlab notifications", Icon: "mdi-alert", Modules: []modeModuleGroup{
+				{Add: []string{"notifications"}},
+			}},
+			{Name: "bluetooth-audio", Icon: "mdi-bluetooth", Modules: []modeModuleGroup{
+				{Add: []string{"soundcore", "headset"}},
+				{Detail: []string{"soundcoreDetail", "headsetDetail"}},
+			}},
+			{Name: "shelly", Icon: "mdi-coffee", Modules: []modeModuleGroup{
+				{Add: []string{"quickMill"}},
+				{Detail: []string{"quickMillDetail"}},
+			}},
+			{Name: "VPN", Icon: "mdi-tunnel-outline", Modules: []modeModuleGroup{
+				{Add: []string{"vpn", "wireguard"}},
+			}},
+			{Name: "network", Icon: "mdi-ethernet", Modules: []modeModuleGroup{
+				{Summary: []string{"wifi"}},
+				{Detail: []string{"wifiDetail", "netinfo", "netspeed", "probes"}},
+			}},
+			{Name: "media", Icon: "mdi-music-box", Modules: []modeModuleGroup{
+				{Add: []string{"volume", "microphone", "media", "mediaDetail"}},
+				{Detail: []string{"volumeDetail", "audioOutputs"}},
+			}},
+			{Name: "battery", Modules: []modeModuleGroup{
+				{Summary: []string{"battery"}},
+				{Detail: []string{"batteryDetail"}},
+			}},
+			{Name: "timezones", Icon: "mdi-earth", Modules: []modeModuleGroup{
+				{Detail: []string{"timezones"}},
+			}},
+		},
+		Right: []string{"localdate", "localtime"},
+	}
+}
+
+// moduleNames returns the names of the modules available to the layout.
+// Building the modules doesn't start them, so layouts can be validated
+// without running the bar.
+func moduleNames() []string {
+	return slices.Sorted(maps.Keys(barModules()))
+}
+
+// layoutErr holds the error of the last buildBar call, if any.
+var layoutErr value.Value // of error
+
+// mainModal holds the modal.Controller of the current layout. buildBar
+// replaces it on every rebuild, modules reach it via toggleMode and
+// setModeOutput.
+var mainModal value.Value // of modal.Controller
+
+// toggleMode toggles between the named mode and no active mode.
+func toggleMode(name string) {
+	if c, ok := mainModal.Get().(modal.Controller); ok {
+		c.Toggle(name)
+	}
+}
+
+// setModeOutput sets the segment shown for the named mode.
+func setModeOutput(name string, s *bar.Segment) {
+	if c, ok := mainModal.Get().(modal.Controller); ok {
+		c.SetOutput(name, s)
+	}
+}
+
+// buildBar returns a func that arranges the named modules as configured in
+// the layout. The bar is rebuilt whenever the layout changes, so it only shows
+// views of the shared modules, which end together with the layout. A nil
+// module is known but not available on this machine and is skipped.
+//
+// The modal of a replaced layout stays behind, but idle: it holds no timers
+// or subscriptions once its views ended.
+func buildBar(modules map[string]*sharedModule) func(config, <-chan struct{}) bar.Module {
+	return func(cfg config, done <-chan struct{}) bar.Module {
+		var err error
+		lookup := func(names []string) []bar.Module {
+			var found []bar.Module
+			for _, name := range names {
+				m, ok := modules[name]
+				if !ok {
+					err = fmt.Errorf("layout: unknown module %q", name)
+					continue
+				}
+				if m != nil {
+					found = append(found, m.view(done))
+				}
+			}
+			return found
+		}
+
+		layoutModal := modal.New()
+		for _, modeCfg := range cfg.Layout.Modes {
+			mode := layoutModal.Mode(modeCfg.Name)
+			if modeCfg.Icon != "" {
+				mode.SetOutput(makeIconOutput(modeCfg.Icon))
+			} else {
+				mode.SetOutput(nil)
+			}
+			for _, g := range modeCfg.Modules {
+				mode.Add(lookup(g.Add)...)
+				mode.Summary(lookup(g.Summary)...)
+				mode.Detail(lookup(g.Detail)...)
+			}
+		}
+
+		right := lookup(cfg.Layout.Right)
+		layoutErr.Set(err)
+
+		mm, controller := layoutModal.Build()
+		mainModal.Set(controller)
+		return stack(done, append([]bar.Module{mm}, right...)...)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/modules/media"
+	"github.com/godbus/dbus/v5"
+)
+
+// e.g.:
+// media:
+//
+//	players: [spotify, firefox]
+//	marquee:
+//	  width: 40
+//	  speed: 300ms
+//
+// The media mode follows the player that is playing. If several are, the
+// first one in players wins, players not listed come last. Entries match
+// instance names as well, e.g. firefox matches firefox.instance_1_42.
+//
+// With a marquee width, titles longer than width columns scroll by one
+// character every speed while playing instead of being truncated.
+type mediaConfig struct {
+	Players []string `koanf:"players"`
+	Marquee struct {
+		Width int           `koanf:"width"`
+		Speed time.Duration `koanf:"speed"`
+	} `koanf:"marquee"`
+}
+
+const (
+	mprisPrefix = "org.mpris.MediaPlayer2."
+	mprisPath   = "/org/mpris/MediaPlayer2"
+	mprisPlayer = "org.mpris.MediaPlayer2.Player"
+)
+
+// MPRIS LoopStatus values.
+const (
+	loopNone     = "None"
+	loopTrack    = "Track"
+	loopPlaylist = "Playlist"
+)
+
+// mediaPlayer shows the active player, see followActivePlayer.
+var mediaPlayer = media.New("spotify")
+
+// mprisLoop caches the LoopStatus of the followed player, which the media
+// module doesn't track. followActivePlayer reads it again on player updates.
+var mprisLoop value.Value // of string
+
+// mprisLoopStatus reads the LoopStatus of player.
+func mprisLoopStatus(player string) string {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return loopNone
+	}
+	v, err := conn.Object(mprisPrefix+player, mprisPath).GetProperty(mprisPlayer + ".LoopStatus")
+	if err != nil {
+		return loopNone
+	}
+	loop, _ := v.Value().(string)
+	return loop
+}
+
+func nextLoopStatus(loop string) string {
+	switch loop {
+	case loopNone:
+		return loopPlaylist
+	case loopPlaylist:
+		return loopTrack
+	default:
+		return loopNone
+	}
+}
+
+func setMprisProperty(player, property string, value interface{}) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if err := conn.Object(mprisPrefix+player, mprisPath).
+		Call("org.freedesktop.DBus.Properties.Set", 0, mprisPlayer, property, dbus.MakeVariant(value)).
+		Err; err != nil {
+		log.Print(err)
+	}
+}
+
+// playerRank returns the position of player in priority, len(priority) if
+// it isn't listed.
+func playerRank(player string, priority []string) int {
+	for i, p := range priority {
+		if player == p || strings.HasPrefix(player, p+".") {
+			return i
+		}
+	}
+	return len(priority)
+}
+
+// selectPlayer picks the player to show from all players and their playback
+// status: the best ranked player that is playing, or the current one if it is
+// still there, or the best ranked one otherwise. Among equally ranked players
+// the current one is kept.
+func selectPlayer(current string, status map[string]string, priority []string) string {
+	better := func(a, b string) bool {
+		ra, rb := playerRank(a, priority), playerRank(b, priority)
+		switch {
+		case ra != rb:
+			return ra < rb
+		case a == current || b == current:
+			return a == current
+		default:
+			return a < b
+		}
+	}
+
+	best := ""
+	for player, s := range status {
+		if s == string(media.Playing) && (best == "" || better(player, best)) {
+			best = player
+		}
+	}
+	if best != "" {
+		return best
+	}
+	if _, ok := status[current]; ok {
+		return current
+	}
+	for player := range status {
+		if best == "" || better(player, best) {
+			best = player
+		}
+	}
+	if best == "" {
+		return current
+	}
+	return best
+}
+
+// followActivePlayer switches m to the active MPRIS player whenever a player
+// appears, disappears or changes its playback status.
+func followActivePlayer(m *media.Module, current string) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, match := range [][]dbus.MatchOption{
+		{
+			dbus.WithMatchInterface("org.freedesktop.DBus"),
+			dbus.WithMatchMember("NameOwnerChanged"),
+			dbus.WithMatchOption("arg0namespace", "org.mpris.MediaPlayer2"),
+		},
+		{
+			dbus.WithMatchObjectPath(mprisPath),
+			dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"),
+		},
+	} {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			log.Print(err)
+			return
+		}
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	go func() {
+		for {
+			next := liveConfig.Next()
+			if player := selectPlayer(current, playerStatus(conn), liveConfig.Get().(config).Media.Players); player != current {
+				current = player
+				m.Player(current)
+			}
+			if loop := mprisLoopStatus(current); loop != mprisLoop.Get() {
+				mprisLoop.Set(loop)
+			}
+			select {
+			case <-signals:
+				// players send several signals per change
+				time.Sleep(100 * time.Millisecond)
+				for len(signals) > 0 {
+					<-signals
+				}
+			case <-next:
+			}
+		}
+	}()
+}
+
+// playerStatus returns the playback status of all MPRIS players by name.
+func playerStatus(conn *dbus.Conn) map[string]string {
+	status := map[string]string{}
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		return status
+	}
+	for _, name := range names {
+		player, ok := strings.CutPrefix(name, mprisPrefix)
+		if !ok {
+			continue
+		}
+		v, err := conn.Object(name, mprisPath).GetProperty(mprisPlayer + ".PlaybackStatus")
+		if err != nil {
+			continue
+		}
+		s, _ := v.Value().(string)
+		status[player] = s
+	}
+	return status
+}
@@ -0,0 +1,125 @@
+// Package nm lists the WiFi connections known to NetworkManager and activates
+// them over D-Bus.
+package nm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	nmName     = "org.freedesktop.NetworkManager"
+	nmPath     = "/org/freedesktop/NetworkManager"
+	nmSettings = "/org/freedesktop/NetworkManager/Settings"
+)
+
+// Network is a WiFi connection profile.
+type Network struct {
+	ID   string
+	SSID string
+	// Strength is the signal strength in percent of the strongest access
+	// point with the network's SSID, or -1 if it is out of range.
+	Strength int
+
+	path dbus.ObjectPath
+}
+
+// InRange returns true if an access point of the network is visible.
+func (n Network) InRange() bool {
+	return n.Strength >= 0
+}
+
+// KnownNetworks returns the WiFi connections of NetworkManager, the ones in
+// range of iface first, strongest first.
+func KnownNetworks(iface string) ([]Network, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []dbus.ObjectPath
+	if err := conn.Object(nmName, nmSettings).
+		Call(nmName+".Settings.ListConnections", 0).Store(&paths); err != nil {
+		return nil, err
+	}
+
+	visible := visibleSSIDs(conn, iface)
+	var networks []Network
+	for _, path := range paths {
+		var settings map[string]map[string]dbus.Variant
+		if err := conn.Object(nmName, path).
+			Call(nmName+".Settings.Connection.GetSettings", 0).Store(&settings); err != nil {
+			continue
+		}
+		if t, _ := settings["connection"]["type"].Value().(string); t != "802-11-wireless" {
+			continue
+		}
+		id, _ := settings["connection"]["id"].Value().(string)
+		ssid, _ := settings["802-11-wireless"]["ssid"].Value().([]byte)
+		strength, ok := visible[string(ssid)]
+		if !ok {
+			strength = -1
+		}
+		networks = append(networks, Network{ID: id, SSID: string(ssid), Strength: strength, path: path})
+	}
+
+	sort.SliceStable(networks, func(i, j int) bool {
+		return networks[i].Strength > networks[j].Strength
+	})
+	return networks, nil
+}
+
+// visibleSSIDs returns the strongest signal per SSID seen by iface.
+func visibleSSIDs(conn *dbus.Conn, iface string) map[string]int {
+	visible := map[string]int{}
+	device, err := deviceByIface(conn, iface)
+	if err != nil {
+		return visible
+	}
+	aps, err := conn.Object(nmName, device).GetProperty(nmName + ".Device.Wireless.AccessPoints")
+	if err != nil {
+		return visible
+	}
+	paths, _ := aps.Value().([]dbus.ObjectPath)
+	for _, path := range paths {
+		ap := conn.Object(nmName, path)
+		ssid, err := ap.GetProperty(nmName + ".AccessPoint.Ssid")
+		if err != nil {
+			continue
+		}
+		strength, err := ap.GetProperty(nmName + ".AccessPoint.Strength")
+		if err != nil {
+			continue
+		}
+		name, _ := ssid.Value().([]byte)
+		s, _ := strength.Value().(byte)
+		if cur, ok := visible[string(name)]; !ok || int(s) > cur {
+			visible[string(name)] = int(s)
+		}
+	}
+	return visible
+}
+
+func deviceByIface(conn *dbus.Conn, iface string) (dbus.ObjectPath, error) {
+	var device dbus.ObjectPath
+	err := conn.Object(nmName, nmPath).Call(nmName+".GetDeviceByIpIface", 0, iface).Store(&device)
+	return device, err
+}
+
+// Activate connects iface to network.
+func Activate(network Network, iface string) error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	device, err := deviceByIface(conn, iface)
+	if err != nil {
+		return fmt.Errorf("%s: %w", iface, err)
+	}
+	var active dbus.ObjectPath
+	return conn.Object(nmName, nmPath).
+		Call(nmName+".ActivateConnection", 0, network.path, device, dbus.ObjectPath("/")).
+		Store(&active)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/barista-run/barista/timing"
+	"github.com/bavarianbidi/i3-bar/jira"
+	forge "github.com/git-pkgs/forge"
+)
+
+const (
+	forgeBinary     = "go/bin/forge" // relative to $HOME
+	jiraAlertBinary = "/home/comario/bin/jira"
+)
+
+// notifications groups the forge and Jira modules, which all depend on the
+// config file. They end once done is closed.
+func notifications(cfg config, done <-chan struct{}) bar.Module {
+	var modules []bar.Module
+	modules = append(modules, forgeNotifications(cfg.Forges, done)...)
+	modules = append(modules, jiraNotifications(cfg, done)...)
+	return stack(done, modules...)
+}
+
+type commandModule struct {
+	args     []string
+	env      []string
+	interval time.Duration
+	format   func(string) bar.Output
+	done     <-chan struct{}
+}
+
+// command runs args every minute and shows its trimmed output, like barista's
+// shell module. A failing command shows the error and is retried on the next
+// run. Stream returns once done is closed.
+func command(done <-chan struct{}, args ...string) *commandModule {
+	return &commandModule{
+		args:     args,
+		interval: time.Minute,
+		format:   func(s string) bar.Output { return outputs.Text(s) },
+		done:     done,
+	}
+}
+
+// Output sets the output format.
+func (m *commandModule) Output(format func(string) bar.Output) *commandModule {
+	m.format = format
+	return m
+}
+
+// Every sets the interval between runs.
+func (m *commandModule) Every(interval time.Duration) *commandModule {
+	m.interval = interval
+	return m
+}
+
+// WithEnv appends environment variables in KEY=value format.
+func (m *commandModule) WithEnv(env ...string) *commandModule {
+	m.env = append(m.env, env...)
+	return m
+}
+
+func (m *commandModule) Stream(s bar.Sink) {
+	scheduler := timing.NewScheduler().Every(m.interval)
+	defer scheduler.Close()
+
+	for {
+		cmd := exec.Command(m.args[0], m.args[1:]...)
+		if len(m.env) > 0 {
+			cmd.Env = append(os.Environ(), m.env...)
+		}
+		out, err := cmd.Output()
+		if !s.Error(err) {
+			s.Output(m.format(strings.TrimSpace(string(out))))
+		}
+
+		select {
+		case <-scheduler.C:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func forgeNotifications(forgeConfigs []forgeConfig, done <-chan struct{}) []bar.Module {
+	var forges []bar.Module
+	for _, frg := range forgeConfigs {
+		notification := command(done, home(forgeBinary), "notification", "list", "--host", frg.Host, "--unread", "-o", "json").
+			Output(func(s string) bar.Output {
+				if s == "" {
+					return nil
+				}
+
+				var parsed []forge.Notification
+				if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+					return outputs.Text(s)
+				}
+
+				color := colorOk
+				if len(parsed) > 0 {
+					color = colorAlert
+				}
+
+				// return outputs.Text(fmt.Sprintf("%d", len(parsed)))
+				return outputs.Pango(
+					fontIcon(frg.Icon).Alpha(0.6),
+					spacer,
+					pango.Textf("%d", len(parsed)),
+					stateCue(len(parsed) == 0),
+				).Color(color).
+					OnClick(click.Left(func() {
+						_ = exec.Command("xdg-open", frg.OpenURL).Start()
+					}))
+			}).Every(time.Duration(5) * time.Minute)
+
+		forges = append(forges, notification)
+	}
+	return forges
+}
+
+func jiraNotifications(cfg config, done <-chan struct{}) []bar.Module {
+	if !cfg.jiraConfigured() {
+		return nil
+	}
+
+	openJiraAlerts := command(done, jiraAlertBinary, "alert", "list", "--since", "5d", "--json").
+		Output(func(s string) bar.Output {
+			if s == "" {
+				return nil
+			}
+
+			var parsed []map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+				return outputs.Text(s)
+			}
+
+			color := colorOk
+			if len(parsed) > 0 {
+				color = colorAlert
+			}
+
+			return outputs.Pango(
+				fontIcon(cfg.Jira.Icon).Alpha(0.6),
+				spacer,
+				pango.Textf("%d", len(parsed)),
+			).Color(color).
+				OnClick(click.Left(func() {
+					_ = exec.Command("xdg-open", cfg.Jira.OpenURL).Start()
+				}))
+		}).Every(time.Duration(10) * time.Minute).WithEnv("JIRA_API_TOKEN=" + cfg.Jira.Token)
+
+	// the REST API modules need the Jira URL, the alert CLI doesn't
+	if cfg.Jira.URL == "" {
+		return []bar.Module{openJiraAlerts}
+	}
+
+	jiraClient := jira.NewClient(cfg.Jira.URL, cfg.Jira.User, cfg.Jira.Token)
+	if cfg.Jira.SearchPath != "" {
+		jiraClient.SearchPath = cfg.Jira.SearchPath
+	}
+
+	var jiraQueries []bar.Module
+	for _, q := range cfg.Jira.Queries {
+		jiraQueries = append(jiraQueries, jiraQuery(jiraClient, q, done))
+	}
+
+	activeJiraIssue := jiraActiveIssue(jiraClient, cfg.Jira.Icon, done)
+
+	return append([]bar.Module{openJiraAlerts, activeJiraIssue}, jiraQueries...)
+}
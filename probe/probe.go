@@ -0,0 +1,243 @@
+// Package probe provides a module that checks whether a target is reachable
+// and measures its round-trip latency, either with ICMP echo requests (using
+// the ping binary) or by connecting via TCP.
+package probe
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/timing"
+	"github.com/vishvananda/netlink"
+)
+
+// Gateway can be used as host to probe the gateway of the default route.
+const Gateway = "gateway"
+
+// Result is the result of one round of probes.
+type Result struct {
+	Target         string
+	Sent, Received int
+	// RTT is the average round-trip time of all successful probes.
+	RTT time.Duration
+	Err error
+}
+
+// Loss returns the fraction of probes that failed.
+func (r Result) Loss() float64 {
+	if r.Sent == 0 {
+		return 1
+	}
+	return float64(r.Sent-r.Received) / float64(r.Sent)
+}
+
+// Reachable returns true if at least one probe succeeded.
+func (r Result) Reachable() bool {
+	return r.Err == nil && r.Received > 0
+}
+
+// Module probes a target periodically.
+type Module struct {
+	probe      func(target string, count int, timeout time.Duration) Result
+	target     string
+	count      int
+	timeout    time.Duration
+	done       <-chan struct{}
+	scheduler  *timing.Scheduler
+	outputFunc value.Value
+}
+
+func newModule(target string, probe func(string, int, time.Duration) Result) *Module {
+	m := &Module{
+		probe:     probe,
+		target:    target,
+		count:     3,
+		timeout:   2 * time.Second,
+		scheduler: timing.NewScheduler(),
+	}
+	l.Label(m, target)
+	l.Register(m, "outputFunc")
+	m.RefreshInterval(time.Minute)
+
+	m.Output(func(r Result) bar.Output {
+		if !r.Reachable() {
+			return outputs.Textf("%s unreachable", r.Target)
+		}
+		return outputs.Textf("%s %s", r.Target, r.RTT.Round(time.Millisecond))
+	})
+
+	return m
+}
+
+// ICMP creates a module sending echo requests to host.
+func ICMP(host string) *Module {
+	return newModule(host, pingProbe)
+}
+
+// TCP creates a module connecting to address, e.g. "1.1.1.1:53".
+func TCP(address string) *Module {
+	return newModule(address, tcpProbe)
+}
+
+// Count sets the number of probes per round.
+func (m *Module) Count(count int) *Module {
+	if count > 0 {
+		m.count = count
+	}
+	return m
+}
+
+// Timeout sets the timeout of each probe.
+func (m *Module) Timeout(timeout time.Duration) *Module {
+	if timeout > 0 {
+		m.timeout = timeout
+	}
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(Result) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// RefreshInterval configures the polling frequency.
+func (m *Module) RefreshInterval(interval time.Duration) *Module {
+	m.scheduler.Every(interval)
+	return m
+}
+
+// Until ends Stream once done is closed, e.g. when the module is replaced
+// after a config change.
+func (m *Module) Until(done <-chan struct{}) *Module {
+	m.done = done
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	result := m.run()
+
+	outputFunc := m.outputFunc.Get().(func(Result) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+	defer done()
+	defer m.scheduler.Close()
+
+	for {
+		s.Output(outputFunc(result))
+		select {
+		case <-m.scheduler.C:
+			result = m.run()
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(Result) bar.Output)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *Module) run() Result {
+	target := m.target
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		host, port = target, ""
+	}
+	if host == Gateway {
+		gw, err := defaultGateway()
+		if err != nil {
+			return Result{Target: m.target, Err: err}
+		}
+		if target = gw; port != "" {
+			target = net.JoinHostPort(gw, port)
+		}
+	}
+	r := m.probe(target, m.count, m.timeout)
+	r.Target = m.target
+	return r
+}
+
+func tcpProbe(address string, count int, timeout time.Duration) Result {
+	r := Result{Sent: count}
+	var total time.Duration
+	for i := 0; i < count; i++ {
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			r.Err = err
+			continue
+		}
+		total += time.Since(start)
+		conn.Close()
+		r.Received++
+	}
+	if r.Received > 0 {
+		r.RTT = total / time.Duration(r.Received)
+		r.Err = nil
+	}
+	return r
+}
+
+var (
+	pingReceived = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	pingRTT      = regexp.MustCompile(`= [\d.]+/([\d.]+)/`)
+)
+
+// pingProbe runs ping, which is allowed to send echo requests without being
+// root, and parses its summary:
+//
+//	3 packets transmitted, 3 received, 0% packet loss, time 2003ms
+//	rtt min/avg/max/mdev = 8.018/9.270/10.113/0.905 ms
+func pingProbe(host string, count int, timeout time.Duration) Result {
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	out, err := exec.Command("ping", "-q", "-n",
+		"-c", strconv.Itoa(count), "-W", strconv.Itoa(seconds), host).Output()
+
+	r := Result{Sent: count}
+	match := pingReceived.FindSubmatch(out)
+	if match == nil {
+		if err == nil {
+			err = fmt.Errorf("unexpected ping output %q", out)
+		}
+		r.Err = err
+		return r
+	}
+	r.Sent, _ = strconv.Atoi(string(match[1]))
+	r.Received, _ = strconv.Atoi(string(match[2]))
+	if match := pingRTT.FindSubmatch(out); match != nil {
+		ms, _ := strconv.ParseFloat(string(match[1]), 64)
+		r.RTT = time.Duration(ms * float64(time.Millisecond))
+	}
+	return r
+}
+
+// defaultGateway returns the gateway of the IPv4 default route with the
+// lowest metric.
+func defaultGateway() (string, error) {
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4,
+		&netlink.Route{Table: syscall.RT_TABLE_MAIN}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return "", err
+	}
+	var best *netlink.Route
+	for i, r := range routes {
+		if r.Dst != nil || r.Gw == nil {
+			continue
+		}
+		if best == nil || r.Priority < best.Priority {
+			best = &routes[i]
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no default gateway")
+	}
+	return best.Gw.String(), nil
+}
@@ -0,0 +1,68 @@
+package probe
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	r := TCP(ln.Addr().String()).Count(3).Timeout(time.Second).run()
+	if !r.Reachable() {
+		t.Fatalf("run() = %+v, want reachable", r)
+	}
+	if r.Target != ln.Addr().String() {
+		t.Errorf("Target = %q, want %q", r.Target, ln.Addr().String())
+	}
+	if r.Sent != 3 || r.Received != 3 {
+		t.Errorf("Sent/Received = %d/%d, want 3/3", r.Sent, r.Received)
+	}
+	if r.Loss() != 0 {
+		t.Errorf("Loss() = %v, want 0", r.Loss())
+	}
+	if r.RTT <= 0 || r.RTT > time.Second {
+		t.Errorf("RTT = %v, want within (0, 1s]", r.RTT)
+	}
+}
+
+func TestTCPProbeClosedPort(t *testing.T) {
+	// grab a free port and close it again, so that nothing listens on it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	r := tcpProbe(address, 2, time.Second)
+	if r.Reachable() {
+		t.Fatalf("tcpProbe() = %+v, want unreachable", r)
+	}
+	if r.Err == nil {
+		t.Error("Err = nil, want the dial error")
+	}
+	if r.Sent != 2 || r.Received != 0 {
+		t.Errorf("Sent/Received = %d/%d, want 2/0", r.Sent, r.Received)
+	}
+	if r.Loss() != 1 {
+		t.Errorf("Loss() = %v, want 1", r.Loss())
+	}
+	if r.RTT != 0 {
+		t.Errorf("RTT = %v, want 0", r.RTT)
+	}
+}
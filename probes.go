@@ -0,0 +1,93 @@
+package main
+
+import (
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/probe"
+)
+
+// e.g.:
+// probes:
+//   - label: gw
+//     icmp: gateway
+//   - label: dns
+//     tcp: 1.1.1.1:53
+//   - label: git
+//     tcp: git.internal:443
+//     count: 3
+//     timeout: 2s
+//     interval: 1m
+//     warn: 100ms
+//     alert: 500ms
+//
+// Each probe either pings (icmp) or connects to (tcp) its target, "gateway"
+// stands for the gateway of the default route. Probes with packet loss or a
+// latency above warn are degraded, above alert bad.
+type probeConfig struct {
+	Label    string        `koanf:"label"`
+	ICMP     string        `koanf:"icmp"`
+	TCP      string        `koanf:"tcp"`
+	Count    int           `koanf:"count"`
+	Timeout  time.Duration `koanf:"timeout"`
+	Interval time.Duration `koanf:"interval"`
+	Warn     time.Duration `koanf:"warn"`
+	Alert    time.Duration `koanf:"alert"`
+}
+
+func defaultProbes() []probeConfig {
+	return []probeConfig{
+		{Label: "gw", ICMP: probe.Gateway, Warn: 50 * time.Millisecond, Alert: 200 * time.Millisecond},
+	}
+}
+
+func connectivityProbe(p probeConfig, done <-chan struct{}) bar.Module {
+	var m *probe.Module
+	if p.TCP != "" {
+		m = probe.TCP(p.TCP)
+	} else {
+		m = probe.ICMP(p.ICMP)
+	}
+	if p.Interval > 0 {
+		m.RefreshInterval(p.Interval)
+	}
+
+	return m.Count(p.Count).Timeout(p.Timeout).Until(done).Output(func(r probe.Result) bar.Output {
+		label := p.Label
+		if label == "" {
+			label = r.Target
+		}
+
+		if !r.Reachable() {
+			return outputs.Pango(
+				fontIcon("mdi-lan-disconnect").Alpha(0.6), spacer,
+				pango.Text(label).Small(), spacer,
+				"unreachable",
+			).Color(colorWarn)
+		}
+
+		nodes := []interface{}{
+			fontIcon("mdi-lan-connect").Alpha(0.6), spacer,
+			pango.Text(label).Small(), spacer,
+			r.RTT.Round(time.Millisecond).String(),
+		}
+		if loss := r.Loss(); loss > 0 {
+			nodes = append(nodes, spacer, pango.Textf("%.0f%% loss", loss*100).Small())
+		}
+		out := outputs.Pango(nodes...)
+		return threshold(out, false,
+			p.Alert > 0 && r.RTT > p.Alert,
+			r.Loss() > 0 || p.Warn > 0 && r.RTT > p.Warn,
+		)
+	})
+}
+
+func connectivityProbes(cfg config, done <-chan struct{}) bar.Module {
+	var modules []bar.Module
+	for _, p := range cfg.Probes {
+		modules = append(modules, connectivityProbe(p, done))
+	}
+	return stack(done, modules...)
+}
@@ -0,0 +1,411 @@
+// Package pulse provides modules for PulseAudio devices, and PipeWire ones
+// through pipewire-pulse. It uses pactl, so it needs neither cgo nor a
+// client library, and follows the default device as it changes, e.g. when
+// bluetooth headphones connect.
+package pulse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+)
+
+// DeviceType is either Sink or Source.
+type DeviceType string
+
+const (
+	Sink   DeviceType = "sink"
+	Source DeviceType = "source"
+)
+
+// Device is a sink or source.
+type Device struct {
+	Type        DeviceType
+	Index       int
+	Name        string
+	Description string
+	// State is RUNNING, IDLE or SUSPENDED.
+	State string
+	Mute  bool
+	// Volume is the average volume of all channels in percent.
+	Volume int
+	// Streams is the number of streams playing to a sink or recording from
+	// a source.
+	Streams int
+}
+
+// pactlDevice is an entry of `pactl -f json list sinks`.
+type pactlDevice struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	Mute        bool   `json:"mute"`
+	Volume      map[string]struct {
+		ValuePercent string `json:"value_percent"`
+	} `json:"volume"`
+}
+
+func pactl(args ...string) ([]byte, error) {
+	out, err := exec.Command("pactl", args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("pactl %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// List returns all devices of type t.
+func List(t DeviceType) ([]Device, error) {
+	out, err := pactl("-f", "json", "list", string(t)+"s")
+	if err != nil {
+		return nil, err
+	}
+	var raw []pactlDevice
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, err
+	}
+
+	streams, err := streamsByDevice(t)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]Device, 0, len(raw))
+	for _, r := range raw {
+		d := Device{
+			Type:        t,
+			Index:       r.Index,
+			Name:        r.Name,
+			Description: r.Description,
+			State:       r.State,
+			Mute:        r.Mute,
+			Streams:     streams[r.Index],
+		}
+		for _, ch := range r.Volume {
+			pct, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(ch.ValuePercent), "%"))
+			d.Volume += pct
+		}
+		if len(r.Volume) > 0 {
+			d.Volume /= len(r.Volume)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// pactlStream is an entry of `pactl -f json list sink-inputs` or
+// source-outputs.
+type pactlStream struct {
+	Index      int                    `json:"index"`
+	Sink       int                    `json:"sink"`
+	Source     int                    `json:"source"`
+	Corked     bool                   `json:"corked"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// active reports if audio flows through s. Corked streams are paused, and
+// peak detect streams of volume meters like pavucontrol's only watch levels.
+// PulseAudio names those "Peak detect", PipeWire marks them as monitors.
+func (s pactlStream) active() bool {
+	if s.Corked {
+		return false
+	}
+	return s.Properties["media.name"] != "Peak detect" && s.Properties["stream.monitor"] != "true"
+}
+
+func listStreams(t DeviceType) ([]pactlStream, error) {
+	kind := "sink-inputs"
+	if t == Source {
+		kind = "source-outputs"
+	}
+	out, err := pactl("-f", "json", "list", kind)
+	if err != nil {
+		return nil, err
+	}
+	var streams []pactlStream
+	return streams, json.Unmarshal(out, &streams)
+}
+
+// streamsByDevice counts the active streams per device index.
+func streamsByDevice(t DeviceType) (map[int]int, error) {
+	streams, err := listStreams(t)
+	if err != nil {
+		return nil, err
+	}
+	count := map[int]int{}
+	for _, s := range streams {
+		if !s.active() {
+			continue
+		}
+		if t == Source {
+			count[s.Source]++
+		} else {
+			count[s.Sink]++
+		}
+	}
+	return count, nil
+}
+
+// DefaultName returns the name of the default device of type t.
+func DefaultName(t DeviceType) (string, error) {
+	out, err := pactl("get-default-" + string(t))
+	return strings.TrimSpace(string(out)), err
+}
+
+// Default returns the default device of type t.
+func Default(t DeviceType) (Device, error) {
+	name, err := DefaultName(t)
+	if err != nil {
+		return Device{}, err
+	}
+	return byName(t, name)
+}
+
+// byName returns the device of type t named name.
+func byName(t DeviceType, name string) (Device, error) {
+	devices, err := List(t)
+	if err != nil {
+		return Device{}, err
+	}
+	for _, d := range devices {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Device{}, fmt.Errorf("%s %q not found", t, name)
+}
+
+// AdjustVolume changes the volume by delta percent, staying within 0 and
+// 100%. pactl would happily amplify beyond 100% with relative changes.
+func (d Device) AdjustVolume(delta int) error {
+	// d is the state of the last output, which lags behind when scrolling
+	// fast, so start from the current volume
+	current, err := byName(d.Type, d.Name)
+	if err != nil {
+		return err
+	}
+	volume := min(max(current.Volume+delta, 0), 100)
+	_, err = pactl("set-"+string(d.Type)+"-volume", d.Name, fmt.Sprintf("%d%%", volume))
+	return err
+}
+
+// ToggleMute mutes or unmutes the device.
+func (d Device) ToggleMute() error {
+	_, err := pactl("set-"+string(d.Type)+"-mute", d.Name, "toggle")
+	return err
+}
+
+// Activate makes the device the default and moves all active streams to it,
+// e.g. everything that is playing to a new sink. Paused streams stay where
+// they are. Streams that fail to move don't keep the others from moving.
+func (d Device) Activate() error {
+	if _, err := pactl("set-default-"+string(d.Type), d.Name); err != nil {
+		return err
+	}
+	streams, err := listStreams(d.Type)
+	if err != nil {
+		return err
+	}
+	move := "move-sink-input"
+	if d.Type == Source {
+		move = "move-source-output"
+	}
+	var errs []error
+	for _, s := range streams {
+		if !s.active() {
+			continue
+		}
+		if _, err := pactl(move, strconv.Itoa(s.Index), d.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// events is set on every event of `pactl subscribe`.
+var (
+	events      value.Value // of string
+	watchEvents sync.Once
+)
+
+// Events returns a channel that is closed on the next change of any sink,
+// source, stream or the server (i.e. the default devices).
+func Events() <-chan struct{} {
+	watchEvents.Do(func() { go subscribe() })
+	return events.Next()
+}
+
+func subscribe() {
+	for {
+		cmd := exec.Command("pactl", "subscribe")
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err == nil {
+			s := bufio.NewScanner(stdout)
+			for s.Scan() {
+				// e.g. "Event 'change' on sink #56"
+				events.Set(s.Text())
+			}
+			err = cmd.Wait()
+		}
+		l.Log("pactl subscribe: %v", err)
+		// the server restarted or isn't there yet
+		time.Sleep(5 * time.Second)
+		events.Set("")
+	}
+}
+
+// Module shows the default device of a type.
+type Module struct {
+	deviceType DeviceType
+	outputFunc value.Value
+}
+
+// DefaultSink creates a module for the default sink.
+func DefaultSink() *Module {
+	return newModule(Sink)
+}
+
+// DefaultSource creates a module for the default source.
+func DefaultSource() *Module {
+	return newModule(Source)
+}
+
+func newModule(t DeviceType) *Module {
+	m := &Module{deviceType: t}
+	l.Label(m, string(t))
+	l.Register(m, "outputFunc")
+
+	m.Output(func(d Device) bar.Output {
+		if d.Mute {
+			return outputs.Text("MUT")
+		}
+		return outputs.Textf("%d%%", d.Volume)
+	})
+
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(Device) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	outputFunc := m.outputFunc.Get().(func(Device) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+	defer done()
+
+	next := Events()
+	device, err := Default(m.deviceType)
+	for {
+		if err != nil {
+			s.Output(outputs.Error(err))
+		} else {
+			s.Output(outputFunc(device))
+		}
+		select {
+		case <-next:
+			// events come in bursts, e.g. for every stream of a device
+			time.Sleep(50 * time.Millisecond)
+			next = Events()
+			device, err = Default(m.deviceType)
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(Device) bar.Output)
+		}
+	}
+}
+
+// DeviceList is the list of all devices of a type.
+type DeviceList struct {
+	Devices []Device
+	// Default is the name of the default device.
+	Default string
+}
+
+// ListModule shows all devices of a type.
+type ListModule struct {
+	deviceType DeviceType
+	outputFunc value.Value
+}
+
+// Sinks creates a module for all sinks.
+func Sinks() *ListModule {
+	return newListModule(Sink)
+}
+
+// Sources creates a module for all sources.
+func Sources() *ListModule {
+	return newListModule(Source)
+}
+
+func newListModule(t DeviceType) *ListModule {
+	m := &ListModule{deviceType: t}
+	l.Label(m, string(t)+"s")
+	l.Register(m, "outputFunc")
+
+	m.Output(func(list DeviceList) bar.Output {
+		out := outputs.Group()
+		for _, d := range list.Devices {
+			out.Append(outputs.Text(d.Description))
+		}
+		return out
+	})
+
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *ListModule) Output(outputFunc func(DeviceList) bar.Output) *ListModule {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// Stream starts the module.
+func (m *ListModule) Stream(s bar.Sink) {
+	outputFunc := m.outputFunc.Get().(func(DeviceList) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+	defer done()
+
+	next := Events()
+	list, err := m.list()
+	for {
+		if err != nil {
+			s.Output(outputs.Error(err))
+		} else {
+			s.Output(outputFunc(list))
+		}
+		select {
+		case <-next:
+			time.Sleep(50 * time.Millisecond)
+			next = Events()
+			list, err = m.list()
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(DeviceList) bar.Output)
+		}
+	}
+}
+
+func (m *ListModule) list() (DeviceList, error) {
+	name, err := DefaultName(m.deviceType)
+	if err != nil {
+		return DeviceList{}, err
+	}
+	devices, err := List(m.deviceType)
+	return DeviceList{Devices: devices, Default: name}, err
+}
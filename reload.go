@@ -0,0 +1,211 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/core"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/barista-run/barista/sink"
+)
+
+type reloadableModule struct {
+	build func(config, <-chan struct{}) bar.Module
+	key   func(config) interface{}
+}
+
+// reloadable returns a module that streams build(cfg, done) for the live
+// config and rebuilds it whenever the part of the config returned by key
+// changes. done is closed once the module is replaced, its Stream has to
+// return then and release its timers and subscriptions, see stack and the
+// Until methods of the local modules.
+func reloadable(build func(config, <-chan struct{}) bar.Module, key func(config) interface{}) bar.Module {
+	return reloadableModule{build, key}
+}
+
+func (r reloadableModule) Stream(s bar.Sink) {
+	next, unsubscribe := liveConfig.Subscribe()
+	defer unsubscribe()
+
+	// mu orders outputs of an outdated module before those of its successor
+	var mu sync.Mutex
+	cfg := liveConfig.Get().(config)
+	for {
+		done := make(chan struct{})
+		go r.build(cfg, done).Stream(func(o bar.Output) {
+			mu.Lock()
+			defer mu.Unlock()
+			select {
+			case <-done:
+				// the last outputs of an outdated module are dropped
+			default:
+				s.Output(o)
+			}
+		})
+
+		for changed := false; !changed; {
+			<-next
+			newCfg := liveConfig.Get().(config)
+			changed = !reflect.DeepEqual(r.key(cfg), r.key(newCfg))
+			cfg = newCfg
+		}
+		mu.Lock()
+		close(done)
+		mu.Unlock()
+	}
+}
+
+type stackModule struct {
+	modules []bar.Module
+	done    <-chan struct{}
+}
+
+// stack shows modules next to each other like group.Simple, but returns from
+// Stream once done is closed. The modules have to end on done as well.
+func stack(done <-chan struct{}, modules ...bar.Module) bar.Module {
+	return stackModule{modules, done}
+}
+
+func (m stackModule) Stream(s bar.Sink) {
+	if len(m.modules) == 0 {
+		// clear what a replaced module left behind
+		s.Output(nil)
+	}
+
+	var mu sync.Mutex
+	outs := make(stackOutput, len(m.modules))
+	updated := make(chan struct{}, 1)
+	for i, module := range m.modules {
+		go module.Stream(func(o bar.Output) {
+			mu.Lock()
+			outs[i] = o
+			mu.Unlock()
+			select {
+			case updated <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	for {
+		select {
+		case <-updated:
+			mu.Lock()
+			out := slices.Clone(outs)
+			mu.Unlock()
+			s.Output(out)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// stackOutput concatenates the outputs of a stack. It refreshes as soon as
+// the first of its timed outputs does.
+type stackOutput []bar.Output
+
+func (o stackOutput) Segments() []*bar.Segment {
+	var segments []*bar.Segment
+	for _, out := range o {
+		if out != nil {
+			segments = append(segments, out.Segments()...)
+		}
+	}
+	return segments
+}
+
+func (o stackOutput) NextRefresh() time.Time {
+	var next time.Time
+	for _, out := range o {
+		timed, ok := out.(bar.TimedOutput)
+		if !ok {
+			continue
+		}
+		if t := timed.NextRefresh(); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// sharedModule runs a module once and mirrors its output wherever a view of it
+// is added to the bar, like multicast. Unlike multicast, a view ends once the
+// layout it belongs to is replaced.
+type sharedModule struct {
+	*value.Value
+	start func()
+}
+
+func share(original bar.Module) *sharedModule {
+	output, sink := sink.Value()
+	coreModule := core.NewModule(original)
+	var once sync.Once
+	start := func() {
+		once.Do(func() {
+			coreModule.Stream(sink)
+		})
+	}
+	return &sharedModule{output, start}
+}
+
+type sharedView struct {
+	*sharedModule
+	done <-chan struct{}
+}
+
+// view returns a module that mirrors m until done is closed.
+func (m *sharedModule) view(done <-chan struct{}) bar.Module {
+	return sharedView{m, done}
+}
+
+func (v sharedView) Stream(s bar.Sink) {
+	go v.start()
+	for {
+		next := v.Next()
+		segments, _ := v.Get().(bar.Segments)
+		s.Output(segments)
+		select {
+		case <-next:
+		case <-v.done:
+			return
+		}
+	}
+}
+
+type configStatusModule struct{}
+
+// configStatus shows the error of the last config reload or of building the
+// configured layout, if any.
+func configStatus() bar.Module {
+	return configStatusModule{}
+}
+
+func (configStatusModule) Stream(s bar.Sink) {
+	for {
+		nextConfigErr, nextLayoutErr := configErr.Next(), layoutErr.Next()
+
+		err, _ := configErr.Get().(error)
+		if err == nil {
+			err, _ = layoutErr.Get().(error)
+		}
+		if err != nil {
+			s.Output(outputs.Pango(
+				fontIcon("mdi-file-alert-outline"),
+				spacer,
+				pango.Text(truncate(err.Error(), 80)),
+			).Color(colorAlert))
+		} else {
+			s.Output(nil)
+		}
+
+		select {
+		case <-nextConfigErr:
+		case <-nextLayoutErr:
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package routespeed provides a module for the network utilisation of the
+// interface the default route currently goes through, following it when it
+// changes, e.g. after docking or switching to WiFi.
+package routespeed
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/format"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/timing"
+	"github.com/martinlindhe/unit"
+	"github.com/vishvananda/netlink"
+)
+
+// Speeds is the traffic on the default route interface.
+type Speeds struct {
+	// Iface is empty if there is no default route.
+	Iface  string
+	Rx, Tx unit.Datarate
+}
+
+// Total gets the total speed (both up and down).
+func (s Speeds) Total() unit.Datarate {
+	return s.Rx + s.Tx
+}
+
+// Module tracks the default route interface and its speeds.
+type Module struct {
+	scheduler  *timing.Scheduler
+	outputFunc value.Value
+}
+
+// New creates a module for the default route interface.
+func New() *Module {
+	m := &Module{scheduler: timing.NewScheduler()}
+	l.Register(m, "scheduler", "outputFunc")
+	m.RefreshInterval(3 * time.Second)
+
+	m.Output(func(s Speeds) bar.Output {
+		if s.Iface == "" {
+			return nil
+		}
+		return outputs.Textf("%s: %s up | %s down",
+			s.Iface, format.IByterate(s.Tx), format.IByterate(s.Rx))
+	})
+
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(Speeds) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// RefreshInterval configures the polling frequency. Speeds are averaged over
+// this interval.
+func (m *Module) RefreshInterval(interval time.Duration) *Module {
+	m.scheduler.Every(interval)
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	done := make(chan struct{})
+	defer close(done)
+	routes := make(chan netlink.RouteUpdate, 16)
+	if err := netlink.RouteSubscribe(routes, done); err != nil {
+		l.Log("route subscription failed: %v", err)
+		routes = nil
+	}
+
+	outputFunc := m.outputFunc.Get().(func(Speeds) bar.Output)
+	nextOutputFunc, doneOutputFunc := m.outputFunc.Subscribe()
+	defer doneOutputFunc()
+
+	var speeds Speeds
+	var lastRx, lastTx uint64
+	// lastOK is false if the last read failed, lastRx/lastTx are unusable then
+	var lastOK bool
+	lastRead := timing.Now()
+	// rebind starts over on the current default route interface, speeds are
+	// available from the next refresh on.
+	rebind := func() {
+		iface := defaultRouteIface()
+		if iface == speeds.Iface {
+			return
+		}
+		speeds = Speeds{Iface: iface}
+		var err error
+		lastRx, lastTx, err = rxTx(iface)
+		lastOK = err == nil
+		lastRead = timing.Now()
+	}
+	rebind()
+
+	for {
+		s.Output(outputFunc(speeds))
+		select {
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(Speeds) bar.Output)
+		case _, ok := <-routes:
+			if !ok {
+				routes = nil
+				continue
+			}
+			for len(routes) > 0 {
+				<-routes
+			}
+			rebind()
+		case <-m.scheduler.C:
+			iface := speeds.Iface
+			if rebind(); speeds.Iface != iface {
+				continue
+			}
+			rx, tx, err := rxTx(iface)
+			if err != nil {
+				speeds, lastOK = Speeds{}, false
+				continue
+			}
+			now := timing.Now()
+			if !lastOK || rx < lastRx || tx < lastTx {
+				// no previous reading, or the counters were reset, e.g.
+				// because the interface was recreated: skip this sample
+				lastRead, lastRx, lastTx, lastOK = now, rx, tx, true
+				continue
+			}
+			duration := now.Sub(lastRead).Seconds()
+			speeds.Rx = unit.Datarate(float64(rx-lastRx)/duration) * unit.BytePerSecond
+			speeds.Tx = unit.Datarate(float64(tx-lastTx)/duration) * unit.BytePerSecond
+			lastRead, lastRx, lastTx = now, rx, tx
+		}
+	}
+}
+
+// defaultRouteIface returns the interface of the IPv4 default route with the
+// lowest metric, falling back to IPv6.
+func defaultRouteIface() string {
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := netlink.RouteListFiltered(family,
+			&netlink.Route{Table: syscall.RT_TABLE_MAIN}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			continue
+		}
+		var best *netlink.Route
+		for i, r := range routes {
+			if r.Dst != nil || r.LinkIndex == 0 {
+				continue
+			}
+			if best == nil || r.Priority < best.Priority {
+				best = &routes[i]
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if link, err := netlink.LinkByIndex(best.LinkIndex); err == nil {
+			return link.Attrs().Name
+		}
+	}
+	return ""
+}
+
+func rxTx(iface string) (rx, tx uint64, err error) {
+	if iface == "" {
+		return 0, 0, nil
+	}
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return 0, 0, err
+	}
+	stats := link.Attrs().Statistics
+	return stats.RxBytes, stats.TxBytes, nil
+}
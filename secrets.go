@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/knadh/koanf/v2"
+)
+
+// Config values, including those inside lists like forges or jira.queries,
+// may reference secrets instead of containing them:
+//
+//	token: secret:file:~/.config/i3/jira-token
+//	token: secret:env:JIRA_API_TOKEN
+//	token: secret:secret-service:service=jira,username=me
+//
+// secret-service lookups search the default keyring via D-Bus
+// (org.freedesktop.secrets) for an item with all given attributes, e.g. one
+// stored with `secret-tool store --label=jira service jira username me`.
+// Values without the secret: marker are taken literally.
+const (
+	secretPrefix        = "secret:"
+	secretFilePrefix    = "file:"
+	secretEnvPrefix     = "env:"
+	secretServicePrefix = "secret-service:"
+)
+
+const (
+	secretServiceName = "org.freedesktop.secrets"
+	secretServicePath = "/org/freedesktop/secrets"
+)
+
+// secretItem mirrors the Secret struct (oayays) of the Secret Service API.
+type secretItem struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// resolveSecrets replaces all secret references in k by their values.
+func resolveSecrets(k *koanf.Koanf) error {
+	for key, val := range k.All() {
+		resolved, changed, err := resolveSecretValue(key, val)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := k.Set(key, resolved); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// resolveSecretValue resolves the secret references in val, descending into
+// lists and maps. changed reports whether any reference was found.
+func resolveSecretValue(key string, val interface{}) (resolved interface{}, changed bool, err error) {
+	switch v := val.(type) {
+	case string:
+		if !strings.HasPrefix(v, secretPrefix) {
+			return v, false, nil
+		}
+		secret, err := resolveSecret(strings.TrimPrefix(v, secretPrefix))
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", key, err)
+		}
+		return secret, true, nil
+
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			r, c, err := resolveSecretValue(fmt.Sprintf("%s[%d]", key, i), item)
+			if err != nil {
+				return nil, false, err
+			}
+			list[i], changed = r, changed || c
+		}
+		return list, changed, nil
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			r, c, err := resolveSecretValue(key+"."+name, item)
+			if err != nil {
+				return nil, false, err
+			}
+			m[name], changed = r, changed || c
+		}
+		return m, changed, nil
+	}
+	return val, false, nil
+}
+
+// resolveSecret returns the value ref, a reference without the secret: marker,
+// points to.
+func resolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretFilePrefix):
+		b, err := os.ReadFile(expandHome(strings.TrimPrefix(ref, secretFilePrefix)))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+
+	case strings.HasPrefix(ref, secretEnvPrefix):
+		name := strings.TrimPrefix(ref, secretEnvPrefix)
+		secret, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %s not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(ref, secretServicePrefix):
+		attrs := map[string]string{}
+		for _, pair := range strings.Split(strings.TrimPrefix(ref, secretServicePrefix), ",") {
+			k, v, found := strings.Cut(pair, "=")
+			if !found {
+				return "", fmt.Errorf("invalid secret-service attribute %q", pair)
+			}
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return lookupSecretService(attrs)
+	}
+
+	return "", fmt.Errorf("unknown secret reference %q, expected file:, env: or secret-service:", ref)
+}
+
+func lookupSecretService(attrs map[string]string) (string, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return "", err
+	}
+	svc := conn.Object(secretServiceName, secretServicePath)
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := svc.Call("org.freedesktop.Secret.Service.OpenSession", 0, "plain", dbus.MakeVariant("")).
+		Store(&output, &session); err != nil {
+		return "", err
+	}
+	defer conn.Object(secretServiceName, session).Call("org.freedesktop.Secret.Session.Close", 0)
+
+	var unlocked, locked []dbus.ObjectPath
+	if err := svc.Call("org.freedesktop.Secret.Service.SearchItems", 0, attrs).
+		Store(&unlocked, &locked); err != nil {
+		return "", err
+	}
+
+	if len(unlocked) == 0 && len(locked) > 0 {
+		var prompt dbus.ObjectPath
+		if err := svc.Call("org.freedesktop.Secret.Service.Unlock", 0, locked).
+			Store(&unlocked, &prompt); err != nil {
+			return "", err
+		}
+		if len(unlocked) == 0 {
+			// unlocking needs user interaction, which a status bar can't offer
+			return "", fmt.Errorf("secret %v is locked, unlock the keyring first", attrs)
+		}
+	}
+	if len(unlocked) == 0 {
+		return "", fmt.Errorf("no secret found for %v", attrs)
+	}
+
+	var secret secretItem
+	if err := conn.Object(secretServiceName, unlocked[0]).
+		Call("org.freedesktop.Secret.Item.GetSecret", 0, session).
+		Store(&secret); err != nil {
+		return "", err
+	}
+	return string(secret.Value), nil
+}
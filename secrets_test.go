@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+func TestResolveSecretValue(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("I3BAR_TEST_TOKEN", "from-env")
+
+	tests := []struct {
+		name    string
+		val     interface{}
+		want    interface{}
+		changed bool
+	}{
+		{"literal", "plain", "plain", false},
+		{"prefix without marker", "env:I3BAR_TEST_TOKEN", "env:I3BAR_TEST_TOKEN", false},
+		{"non-string", 42, 42, false},
+		{"file", "secret:file:" + secretFile, "from-file", true},
+		{"env", "secret:env:I3BAR_TEST_TOKEN", "from-env", true},
+		{
+			"list",
+			[]interface{}{"a", "secret:env:I3BAR_TEST_TOKEN"},
+			[]interface{}{"a", "from-env"},
+			true,
+		},
+		{
+			"nested",
+			[]interface{}{
+				map[string]interface{}{
+					"host":  "example.com",
+					"token": "secret:file:" + secretFile,
+					"extra": []interface{}{"secret:env:I3BAR_TEST_TOKEN"},
+				},
+			},
+			[]interface{}{
+				map[string]interface{}{
+					"host":  "example.com",
+					"token": "from-file",
+					"extra": []interface{}{"from-env"},
+				},
+			},
+			true,
+		},
+		{
+			"nested without secrets",
+			map[string]interface{}{"a": []interface{}{"b"}},
+			map[string]interface{}{"a": []interface{}{"b"}},
+			false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, changed, err := resolveSecretValue("key", tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) || changed != tc.changed {
+				t.Errorf("resolveSecretValue(%v) = %v, %v, want %v, %v", tc.val, got, changed, tc.want, tc.changed)
+			}
+		})
+	}
+}
+
+func TestResolveSecretValueErrors(t *testing.T) {
+	for _, val := range []interface{}{
+		"secret:env:I3BAR_TEST_UNSET",
+		"secret:file:" + filepath.Join(t.TempDir(), "missing"),
+		"secret:vault:token",
+		"secret:secret-service:service",
+		[]interface{}{map[string]interface{}{"token": "secret:env:I3BAR_TEST_UNSET"}},
+	} {
+		if _, _, err := resolveSecretValue("key", val); err == nil {
+			t.Errorf("resolveSecretValue(%v) succeeded, want an error", val)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("I3BAR_TEST_TOKEN", "from-env")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+jira:
+  token: secret:env:I3BAR_TEST_TOKEN
+forges:
+- host: example.com
+  token: secret:env:I3BAR_TEST_TOKEN
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), yaml.Parser()); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolveSecrets(k); err != nil {
+		t.Fatal(err)
+	}
+	if got := k.String("jira.token"); got != "from-env" {
+		t.Errorf("jira.token = %q, want from-env", got)
+	}
+	forges := k.Slices("forges")
+	if len(forges) != 1 || forges[0].String("token") != "from-env" {
+		t.Errorf("forges = %v, want the token resolved", k.Get("forges"))
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"github.com/barista-run/barista/bar"
 	"github.com/barista-run/barista/base/click"
-	"github.com/barista-run/barista/colors"
 	"github.com/barista-run/barista/modules/meta/split"
 	"github.com/barista-run/barista/outputs"
 	"github.com/barista-run/barista/pango"
@@ -11,71 +10,75 @@ import (
 )
 
 func shellyStatus(address, icon string) (bar.Module, bar.Module) {
-	return split.New(shelly.New(address).
-		//RefreshInterval(3*time.Second).
-		Output(func(s shelly.ShellyState) bar.Output {
+	device := shelly.New(address)
+	//device.RefreshInterval(3 * time.Second)
+	format := func(s shelly.ShellyState) bar.Output {
 
-			color := colorOn
-			iconAppendix := ""
+		color := colorOk
+		iconAppendix := ""
 
-			out := outputs.Group()
+		out := outputs.Group()
 
-			if s.Reachable() {
-				if !s.Connected() {
-					color = colorOff
-					iconAppendix = "-outline"
-				}
-				out.Append(
-					outputs.Pango(
-						pango.Icon("mdi-" + icon + iconAppendix).Color(colors.Hex(color)),
-					))
-
-				out.OnClick(click.Left(func() {
-					s.Toggle()
-				}))
+		if s.Reachable() {
+			if !s.Connected() {
+				color = colorAlert
+				iconAppendix = "-outline"
+			}
+			out.Append(
+				outputs.Pango(
+					fontIcon("mdi-"+icon+iconAppendix).Color(color),
+					stateCue(s.Connected()),
+				))
 
-				if s.IsUpdateAvailable() {
-					out.Append(outputs.Pango(
-						pango.Icon("mdi-package-down").Color(colors.Hex("#34eb55")),
-						spacer,
-						pango.Textf("version %s available", s.GetVersion()),
-					))
-				}
-				if !s.IsUpdateAvailable() {
-					out.Append(outputs.Pango(
-						pango.Icon("mdi-package-down"),
-						spacer,
-						pango.Textf("up to date"),
-					))
-				}
+			out.OnClick(click.Left(func() {
+				s.Toggle()
+			}))
 
+			if s.IsUpdateAvailable() {
 				out.Append(outputs.Pango(
-					pango.Icon("mdi-harddisk"),
+					fontIcon("mdi-package-down").Color(colorOk),
 					spacer,
-					pango.Textf("%.0f%% used", s.DiskUtilization()),
+					pango.Textf("version %s available", s.GetVersion()),
 				))
-
+			}
+			if !s.IsUpdateAvailable() {
 				out.Append(outputs.Pango(
-					pango.Icon("mdi-memory"),
+					fontIcon("mdi-package-down"),
 					spacer,
-					pango.Textf("%.0f%% RAM usage", s.MemoryUtilization()),
+					pango.Textf("up to date"),
 				))
-			} else {
+			}
 
-				color = colorOff
-				iconAppendix = "-off"
+			out.Append(outputs.Pango(
+				fontIcon("mdi-harddisk"),
+				spacer,
+				pango.Textf("%.0f%% used", s.DiskUtilization()),
+			))
 
-				out.Append(
-					outputs.Pango(
-						pango.Icon("mdi-" + icon + iconAppendix).Color(colors.Hex(color)),
-					))
+			out.Append(outputs.Pango(
+				fontIcon("mdi-memory"),
+				spacer,
+				pango.Textf("%.0f%% RAM usage", s.MemoryUtilization()),
+			))
+		} else {
 
-				out.Append(outputs.Pango(
-					spacer,
-					pango.Textf("shelly not reachable"),
+			color = colorAlert
+			iconAppendix = "-off"
+
+			out.Append(
+				outputs.Pango(
+					fontIcon("mdi-"+icon+iconAppendix).Color(color),
+					stateCue(false),
 				))
-			}
 
-			return out
-		}), 1)
+			out.Append(outputs.Pango(
+				spacer,
+				pango.Textf("shelly not reachable"),
+			))
+		}
+
+		return out
+	}
+	onThemeChange(func() { device.Output(format) })
+	return split.New(device.Output(format), 1)
 }
@@ -17,7 +17,7 @@ import (
 func freeMem() *meminfo.Module {
 	return meminfo.New().Output(func(m meminfo.Info) bar.Output {
 		out := outputs.Pango(
-			pango.Icon("material-memory").Alpha(0.8),
+			fontIcon("material-memory").Alpha(0.8),
 			format.IBytesize(m.Available()),
 		)
 		freeGigs := m.Available().Gigabytes()
@@ -27,7 +27,7 @@ func freeMem() *meminfo.Module {
 			freeGigs < 2,
 			freeGigs > 12)
 		out.OnClick(click.Left(func() {
-			mainModalController.Toggle("sysinfo")
+			toggleMode("sysinfo")
 		}))
 		return out
 	})
@@ -36,7 +36,7 @@ func freeMem() *meminfo.Module {
 func swapInfo() *meminfo.Module {
 	return meminfo.New().Output(func(m meminfo.Info) bar.Output {
 		return outputs.Pango(
-			pango.Icon("mdi-swap-horizontal").Alpha(0.8),
+			fontIcon("mdi-swap-horizontal").Alpha(0.8),
 			format.IBytesize(m["SwapTotal"]-m["SwapFree"]), spacer,
 			pango.Textf("(% 2.0f%%)", (1-m.FreeFrac("Swap"))*100.0).Small(),
 		)
@@ -54,7 +54,7 @@ func uptime() *sysinfo.Module {
 			uptimeOut = pango.Textf("%dd%02dh",
 				int(u.Hours()/24), int(u.Hours())%24)
 		}
-		return pango.Icon("mdi-trending-up").Alpha(0.6).Concat(uptimeOut)
+		return fontIcon("mdi-trending-up").Alpha(0.6).Concat(uptimeOut)
 	})
 }
 
@@ -67,7 +67,7 @@ func loadAvgDetail() *sysinfo.Module {
 func loadAvg() *sysinfo.Module {
 	return sysinfo.New().Output(func(s sysinfo.Info) bar.Output {
 		out := outputs.Pango(
-			pango.Icon("mdi-desktop-tower").Alpha(0.6),
+			fontIcon("mdi-desktop-tower").Alpha(0.6),
 			pango.Textf("%0.2f", s.Loads[0]),
 		)
 		// Load averages are unusually high for a few minutes after boot.
@@ -81,7 +81,7 @@ func loadAvg() *sysinfo.Module {
 			s.Loads[0] > 32 || s.Loads[2] > 16,
 		)
 		out.OnClick(click.Left(func() {
-			mainModalController.Toggle("sysinfo")
+			toggleMode("sysinfo")
 		}))
 		return out
 	})
@@ -92,7 +92,7 @@ func cpuTemp() *cputemp.Module {
 		RefreshInterval(2 * time.Second).
 		Output(func(temp unit.Temperature) bar.Output {
 			out := outputs.Pango(
-				pango.Icon("mdi-fan").Alpha(0.6), spacer,
+				fontIcon("mdi-fan").Alpha(0.6), spacer,
 				pango.Textf("%2d℃", int(temp.Celsius())),
 			)
 			threshold(out,
@@ -0,0 +1,144 @@
+// Package tunnel provides a module for the state of a VPN or tunnel
+// interface, e.g. zcctun0, wg0, tun0 or tailscale0.
+package tunnel
+
+import (
+	"syscall"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	"github.com/barista-run/barista/base/watchers/netlink"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	vnl "github.com/vishvananda/netlink"
+)
+
+// State is the state of a tunnel interface.
+type State struct {
+	Name string
+	// Present is false if the interface doesn't exist.
+	Present bool
+	// Up is true if the link is up. Tunnel devices without carrier
+	// detection report an unknown state, which counts as up as well.
+	Up bool
+	// Routes is the number of routes through the interface in all tables
+	// but the local one.
+	Routes int
+	// Err is set if the routes couldn't be listed.
+	Err error
+}
+
+// Connected returns true if the interface is up and routes traffic.
+func (s State) Connected() bool {
+	return s.Up && s.Routes > 0
+}
+
+// Module watches a tunnel interface and updates on link and route changes.
+type Module struct {
+	iface      string
+	done       <-chan struct{}
+	outputFunc value.Value
+}
+
+// New creates a module for the named interface.
+func New(iface string) *Module {
+	m := &Module{iface: iface}
+	l.Label(m, iface)
+	l.Register(m, "outputFunc")
+
+	m.Output(func(s State) bar.Output {
+		if s.Connected() {
+			return outputs.Text(s.Name)
+		}
+		return nil
+	})
+
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(State) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// Until ends Stream once done is closed, e.g. when the module is replaced
+// after a config change.
+func (m *Module) Until(done <-chan struct{}) *Module {
+	m.done = done
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	link := netlink.ByName(m.iface)
+	defer link.Unsubscribe()
+
+	done := make(chan struct{})
+	defer close(done)
+	routes := make(chan vnl.RouteUpdate, 16)
+	if err := vnl.RouteSubscribe(routes, done); err != nil {
+		l.Log("%s: route subscription failed: %v", m.iface, err)
+		routes = nil
+	}
+
+	outputFunc := m.outputFunc.Get().(func(State) bar.Output)
+	nextOutputFunc, doneOutputFunc := m.outputFunc.Subscribe()
+	defer doneOutputFunc()
+
+	state := getState(m.iface, link.Get())
+	for {
+		s.Output(outputFunc(state))
+		select {
+		case <-link.C:
+			state = getState(m.iface, link.Get())
+		case _, ok := <-routes:
+			if !ok {
+				routes = nil
+				continue
+			}
+			// route updates come in bursts, count once for all of them
+			for len(routes) > 0 {
+				<-routes
+			}
+			state = getState(m.iface, link.Get())
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(State) bar.Output)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func getState(iface string, link netlink.Link) State {
+	state := State{Name: iface}
+	switch link.State {
+	case netlink.Gone, netlink.NotPresent:
+		return state
+	case netlink.Up, netlink.Unknown:
+		state.Up = true
+	}
+	state.Present = true
+	state.Routes, state.Err = countRoutes(iface)
+	return state
+}
+
+func countRoutes(iface string) (int, error) {
+	link, err := vnl.LinkByName(iface)
+	if err != nil {
+		return 0, err
+	}
+	routes, err := vnl.RouteListFiltered(vnl.FAMILY_ALL,
+		&vnl.Route{LinkIndex: link.Attrs().Index, Table: syscall.RT_TABLE_UNSPEC},
+		vnl.RT_FILTER_OIF|vnl.RT_FILTER_TABLE)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, r := range routes {
+		if r.Table != syscall.RT_TABLE_LOCAL {
+			count++
+		}
+	}
+	return count, nil
+}
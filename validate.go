@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/barista-run/barista/colors"
+	"github.com/barista-run/barista/pango"
+	"go.yaml.in/yaml/v3"
+)
+
+type problem struct {
+	key string
+	msg string
+}
+
+// configLines maps key paths like "jira.queries[0].jql" to their line in the
+// config file.
+type configLines map[string]int
+
+// find returns the line of key, or of its closest parent for keys missing
+// from the file. It returns 0 if there is none.
+func (c configLines) find(key string) int {
+	for key != "" {
+		if line, ok := c[key]; ok {
+			return line
+		}
+		key = key[:max(strings.LastIndexAny(key, ".["), 0)]
+	}
+	return 0
+}
+
+// validate checks the config file and prints all problems found. It returns
+// the exit code for `i3-bar validate`.
+func validate() int {
+	problems, lines, err := validateConfig(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cfgPath, err)
+		return 1
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return lines.find(problems[i].key) < lines.find(problems[j].key)
+	})
+	for _, p := range problems {
+		if line := lines.find(p.key); line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s: %s\n", cfgPath, line, p.key, p.msg)
+		} else if p.key == "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", cfgPath, p.msg)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", cfgPath, p.key, p.msg)
+		}
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: ok\n", cfgPath)
+	return 0
+}
+
+func validateConfig(path string) ([]problem, configLines, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// koanf drops positions, so parse the file a second time to map keys
+	// back to lines and to find keys config doesn't know about
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, nil, err
+	}
+	lines := configLines{}
+	problems := walkConfig(&doc, reflect.TypeOf(config{}), "", lines)
+
+	// a config that doesn't load still gets its unknown keys reported
+	cfg, err := loadConfig(path)
+	if err != nil {
+		problems = append(problems, problem{"", err.Error()})
+		problems = append(problems, checkBinaries(cfg)...)
+		return problems, lines, nil
+	}
+
+	if err := loadIcons(cfg.Icons); err != nil {
+		problems = append(problems, problem{"icons", err.Error()})
+	}
+
+	problems = append(problems, checkConfig(cfg)...)
+	problems = append(problems, checkTimezones(cfg)...)
+	problems = append(problems, checkBinaries(cfg)...)
+	return problems, lines, nil
+}
+
+// walkConfig records the line of every key below n and reports keys that
+// have no matching koanf tag in t.
+func walkConfig(n *yaml.Node, t reflect.Type, path string, lines configLines) []problem {
+	var problems []problem
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			problems = append(problems, walkConfig(c, t, path, lines)...)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			lines[keyPath] = key.Line
+
+			switch t.Kind() {
+			case reflect.Struct:
+				field, ok := koanfField(t, key.Value)
+				if !ok {
+					problems = append(problems, problem{keyPath, "unknown key"})
+					continue
+				}
+				problems = append(problems, walkConfig(val, field.Type, keyPath, lines)...)
+			case reflect.Map:
+				problems = append(problems, walkConfig(val, t.Elem(), keyPath, lines)...)
+			}
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return problems
+		}
+		for i, c := range n.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			lines[itemPath] = c.Line
+			problems = append(problems, walkConfig(c, t.Elem(), itemPath, lines)...)
+		}
+	}
+
+	return problems
+}
+
+func koanfField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// koanf unmarshals through mapstructure, which matches keys
+		// case-insensitively
+		if strings.EqualFold(f.Tag.Get("koanf"), key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// checkConfig checks for missing required fields and for icons that aren't
+// part of their icon font. Icons of fonts that failed to load are skipped.
+func checkConfig(cfg config) []problem {
+	var problems []problem
+
+	required := func(key, val string) {
+		if val == "" {
+			problems = append(problems, problem{key, "required"})
+		}
+	}
+	icon := func(key, name string) {
+		prefix, _, _ := strings.Cut(name, "-")
+		if loadedIconSets[prefix] && pango.Icon(name).String() == "" {
+			problems = append(problems, problem{key, fmt.Sprintf("unknown icon %q", name)})
+		}
+	}
+
+	for i, f := range cfg.Forges {
+		key := fmt.Sprintf("forges[%d]", i)
+		required(key+".host", f.Host)
+		required(key+".icon", f.Icon)
+		icon(key+".icon", f.Icon)
+	}
+
+	for i, t := range cfg.Tunnels {
+		key := fmt.Sprintf("tunnels[%d]", i)
+		required(key+".interface", t.Interface)
+		icon(key+".icon", t.Icon)
+		for _, cmd := range []struct{ key, command string }{{key + ".up", t.Up}, {key + ".down", t.Down}} {
+			if bin := strings.Fields(cmd.command); len(bin) > 0 {
+				if _, err := exec.LookPath(bin[0]); err != nil {
+					problems = append(problems, problem{cmd.key, err.Error()})
+				}
+			}
+		}
+	}
+
+	for i, p := range cfg.Probes {
+		key := fmt.Sprintf("probes[%d]", i)
+		switch {
+		case p.ICMP == "" && p.TCP == "":
+			problems = append(problems, problem{key, "either icmp or tcp is required"})
+		case p.ICMP != "" && p.TCP != "":
+			problems = append(problems, problem{key + ".tcp", "either icmp or tcp, not both"})
+		case p.TCP != "":
+			if _, _, err := net.SplitHostPort(p.TCP); err != nil {
+				problems = append(problems, problem{key + ".tcp", err.Error()})
+			}
+		}
+		if p.Warn > 0 && p.Alert > 0 && p.Warn > p.Alert {
+			problems = append(problems, problem{key + ".warn", "warn is above alert"})
+		}
+	}
+
+	if cfg.jiraConfigured() {
+		required("jira.url", cfg.Jira.URL)
+		required("jira.token", cfg.Jira.Token)
+	}
+	icon("jira.icon", cfg.Jira.Icon)
+	for i, q := range cfg.Jira.Queries {
+		key := fmt.Sprintf("jira.queries[%d]", i)
+		required(key+".jql", q.JQL)
+		if q.Icon == "" {
+			required(key+".name", q.Name)
+		}
+		icon(key+".icon", q.Icon)
+		if q.Warn > 0 && q.Alert > 0 && q.Warn > q.Alert {
+			problems = append(problems, problem{key + ".warn", "warn is above alert"})
+		}
+	}
+
+	if _, ok := palettes[cfg.Theme.Palette]; cfg.Theme.Palette != "" && !ok {
+		problems = append(problems, problem{"theme.palette", fmt.Sprintf("unknown palette %q", cfg.Theme.Palette)})
+	}
+	switch cfg.Theme.Appearance {
+	case "", appearanceDark, appearanceLight, appearancePortal, appearanceSchedule:
+	default:
+		problems = append(problems, problem{"theme.appearance", fmt.Sprintf("unknown appearance %q", cfg.Theme.Appearance)})
+	}
+	if cfg.Theme.LightHours != "" {
+		if _, _, err := parseWorkHours(cfg.Theme.LightHours); err != nil {
+			problems = append(problems, problem{"theme.lightHours", err.Error()})
+		}
+	}
+	for prefix, roles := range map[string]map[paletteColor]string{
+		"theme.":       cfg.Theme.roleColors().roles(),
+		"theme.dark.":  cfg.Theme.Dark.roles(),
+		"theme.light.": cfg.Theme.Light.roles(),
+	} {
+		for role, hex := range roles {
+			if hex != "" && colors.Hex(hex) == nil {
+				problems = append(problems, problem{prefix + string(role), fmt.Sprintf("invalid color %q", hex)})
+			}
+		}
+	}
+
+	names := moduleNames()
+	module := func(key, name string) {
+		if !slices.Contains(names, name) {
+			problems = append(problems, problem{key, fmt.Sprintf("unknown module %q", name)})
+		}
+	}
+	modes := map[string]bool{}
+	for i, m := range cfg.Layout.Modes {
+		key := fmt.Sprintf("layout.modes[%d]", i)
+		required(key+".name", m.Name)
+		if m.Name != "" && modes[m.Name] {
+			problems = append(problems, problem{key + ".name", fmt.Sprintf("duplicate mode %q", m.Name)})
+		}
+		modes[m.Name] = true
+		icon(key+".icon", m.Icon)
+		for j, g := range m.Modules {
+			for _, f := range []struct {
+				field string
+				names []string
+			}{{"add", g.Add}, {"summary", g.Summary}, {"detail", g.Detail}} {
+				for k, name := range f.names {
+					module(fmt.Sprintf("%s.modules[%d].%s[%d]", key, j, f.field, k), name)
+				}
+			}
+		}
+	}
+	for i, name := range cfg.Layout.Right {
+		module(fmt.Sprintf("layout.right[%d]", i), name)
+	}
+
+	return problems
+}
+
+func checkTimezones(cfg config) []problem {
+	var problems []problem
+	for i, tz := range cfg.Timezones {
+		key := fmt.Sprintf("timezones[%d]", i)
+		if _, err := time.LoadLocation(tz.TZ); err != nil {
+			problems = append(problems, problem{key + ".tz", err.Error()})
+		}
+		if tz.WorkHours == "" {
+			continue
+		}
+		if _, _, err := parseWorkHours(tz.WorkHours); err != nil {
+			problems = append(problems, problem{key + ".workHours", err.Error()})
+		}
+	}
+	return problems
+}
+
+// checkBinaries checks that the commands run by the configured modules are
+// installed.
+func checkBinaries(cfg config) []problem {
+	bins := []string{"xdg-open", "df", "realpath", "pactl", clipboardCommand()[0]}
+	if len(cfg.Forges) > 0 {
+		bins = append(bins, home(forgeBinary))
+	}
+	if cfg.jiraConfigured() {
+		bins = append(bins, jiraAlertBinary)
+	}
+	if slices.ContainsFunc(cfg.Probes, func(p probeConfig) bool { return p.ICMP != "" }) {
+		bins = append(bins, "ping")
+	}
+
+	var problems []problem
+	for _, bin := range bins {
+		if _, err := exec.LookPath(bin); err != nil {
+			problems = append(problems, problem{"binaries", err.Error()})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	"github.com/barista-run/barista/format"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/tunnel"
+	"github.com/bavarianbidi/i3-bar/wireguard"
+)
+
+// e.g.:
+// tunnels:
+//   - interface: wg0
+//     label: office
+//     icon: mdi-vpn
+//     minRoutes: 1
+//     wireguard: true
+//     wgCommand: [sudo, -n, wg]
+//     staleAfter: 3m
+//     up: nmcli connection up office
+//     down: nmcli connection down office
+//     timeout: 30s
+//
+// A tunnel counts as connected once it routes at least minRoutes routes,
+// zscaler e.g. only installs its routes some time after the link is up.
+//
+// For WireGuard tunnels the peers are listed with `wg show`, which needs
+// CAP_NET_ADMIN, hence wgCommand. Peers without a handshake within staleAfter
+// are shown as degraded.
+//
+// With up/down commands, clicking the tunnel connects or disconnects it. The
+// commands run with sh -c and the link has to follow within timeout.
+type tunnelConfig struct {
+	Interface  string        `koanf:"interface"`
+	Label      string        `koanf:"label"`
+	Icon       string        `koanf:"icon"`
+	MinRoutes  int           `koanf:"minRoutes"`
+	WireGuard  bool          `koanf:"wireguard"`
+	WGCommand  []string      `koanf:"wgCommand"`
+	StaleAfter time.Duration `koanf:"staleAfter"`
+	Up         string        `koanf:"up"`
+	Down       string        `koanf:"down"`
+	Timeout    time.Duration `koanf:"timeout"`
+}
+
+func defaultTunnels() []tunnelConfig {
+	return []tunnelConfig{
+		{Interface: "zcctun0", Icon: "mdi-tunnel-outline", MinRoutes: 4},
+	}
+}
+
+// tunnelAction tracks a connect or disconnect started from the bar until the
+// link follows, the command fails or it times out.
+type tunnelAction struct {
+	mu      sync.Mutex
+	pending string // "connecting" or "disconnecting"
+	err     error
+	gen     int
+}
+
+// start runs command unless another action is pending. rerender is called
+// whenever the action state changes.
+func (a *tunnelAction) start(command, pending string, timeout time.Duration, rerender func()) {
+	a.mu.Lock()
+	if a.pending != "" {
+		a.mu.Unlock()
+		return
+	}
+	a.pending, a.err = pending, nil
+	a.gen++
+	gen := a.gen
+	a.mu.Unlock()
+	rerender()
+
+	deadline := time.Now().Add(timeout)
+	go func() {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = errors.New(msg)
+			}
+			a.fail(gen, err)
+			rerender()
+			return
+		}
+		// the command is done, give the link the rest of the timeout
+		time.AfterFunc(time.Until(deadline), func() {
+			a.fail(gen, fmt.Errorf("%s timed out", pending))
+			rerender()
+		})
+	}()
+}
+
+func (a *tunnelAction) fail(gen int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.gen == gen && a.pending != "" {
+		a.pending, a.err = "", err
+	}
+}
+
+// state returns the pending action and the last error, after ending the
+// pending action if the link reached its target state.
+func (a *tunnelAction) state(connected bool) (pending string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pending == "connecting" && connected || a.pending == "disconnecting" && !connected {
+		a.pending = ""
+	}
+	return a.pending, a.err
+}
+
+func tunnelStatus(t tunnelConfig, done <-chan struct{}) bar.Module {
+	if t.Icon == "" {
+		t.Icon = "mdi-tunnel-outline"
+	}
+	if t.MinRoutes < 1 {
+		t.MinRoutes = 1
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = 30 * time.Second
+	}
+
+	m := tunnel.New(t.Interface).Until(done)
+	action := new(tunnelAction)
+	var format func(tunnel.State) bar.Output
+	rerender := func() { m.Output(format) }
+
+	format = func(s tunnel.State) bar.Output {
+		connected := s.Up && s.Routes >= t.MinRoutes
+		pending, err := action.state(connected)
+
+		nodes := []interface{}{fontIcon(t.Icon).Alpha(0.6)}
+		if t.Label != "" {
+			nodes = append(nodes, spacer, pango.Text(t.Label).Small())
+		}
+		switch {
+		case pending != "":
+			nodes = append(nodes, spacer, pango.Text(pending+"…").Small())
+		case err != nil:
+			nodes = append(nodes, spacer, pango.Text(truncate(err.Error(), 40)).Small())
+		default:
+			nodes = append(nodes, stateCue(connected))
+		}
+		out := outputs.Pango(nodes...)
+
+		if connected && t.Down != "" {
+			out.OnClick(click.Left(func() { action.start(t.Down, "disconnecting", t.Timeout, rerender) }))
+		}
+		if !connected && t.Up != "" {
+			out.OnClick(click.Left(func() { action.start(t.Up, "connecting", t.Timeout, rerender) }))
+		}
+
+		switch {
+		case pending != "":
+			return out.Color(colorWarn)
+		case err != nil:
+			return out.Color(colorAlert)
+		case connected:
+			return out.Color(colorOk)
+		case s.Up && s.Err == nil:
+			// link is up, routes aren't there (yet)
+			return out.Color(colorWarn)
+		default:
+			return out.Color(colorAlert)
+		}
+	}
+	return m.Output(format)
+}
+
+func wireguardPeers(t tunnelConfig, done <-chan struct{}) bar.Module {
+	if t.StaleAfter <= 0 {
+		t.StaleAfter = 3 * time.Minute
+	}
+
+	return wireguard.New(t.Interface).Command(t.WGCommand...).Until(done).Output(func(s wireguard.State) bar.Output {
+		if s.Err != nil {
+			return outputs.Pango(
+				fontIcon("mdi-vpn").Alpha(0.6), spacer,
+				pango.Textf("%s: %s", s.Interface, truncate(s.Err.Error(), 40)),
+			).Color(colorAlert)
+		}
+
+		out := outputs.Group()
+		now := time.Now()
+		for _, p := range s.Peers {
+			name := p.Endpoint
+			if name == "" && len(p.AllowedIPs) > 0 {
+				name = p.AllowedIPs[0]
+			}
+			if name == "" {
+				name = truncate(p.PublicKey, 8)
+			}
+
+			age := p.HandshakeAge(now)
+			handshake := "never"
+			if age >= 0 {
+				handshake = age.Round(time.Second).String()
+			}
+
+			peer := outputs.Pango(
+				fontIcon("mdi-vpn").Alpha(0.6), spacer,
+				pango.Text(name), spacer,
+				fontIcon("mdi-handshake-outline").Alpha(0.6), spacer,
+				pango.Text(handshake).Small(), spacer,
+				fontIcon("mdi-download-network").Alpha(0.5), spacer,
+				format.IBytesize(p.Rx), spacer,
+				fontIcon("mdi-upload-network").Alpha(0.5), spacer,
+				format.IBytesize(p.Tx),
+			)
+			out.Append(threshold(peer, false, age < 0, age > t.StaleAfter))
+		}
+		return out
+	})
+}
+
+func tunnels(cfg config, done <-chan struct{}) bar.Module {
+	var modules []bar.Module
+	for _, t := range cfg.Tunnels {
+		modules = append(modules, tunnelStatus(t, done))
+	}
+	return stack(done, modules...)
+}
+
+func wireguardTunnels(cfg config, done <-chan struct{}) bar.Module {
+	var modules []bar.Module
+	for _, t := range cfg.Tunnels {
+		if t.WireGuard {
+			modules = append(modules, wireguardPeers(t, done))
+		}
+	}
+	return stack(done, modules...)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/click"
+	"github.com/barista-run/barista/modules/meta/split"
+	"github.com/barista-run/barista/modules/wlan"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/pango"
+	"github.com/bavarianbidi/i3-bar/nm"
+	"github.com/bavarianbidi/i3-bar/wireless"
+)
+
+// wifiSignalRefresh is how often the signal strength is read while connected,
+// the wlan module itself only updates on connection changes.
+const wifiSignalRefresh = 10 * time.Second
+
+// signalIcon returns a wifi icon with 0 to 4 bars.
+func signalIcon(bars int) string {
+	if bars <= 0 {
+		return "mdi-wifi-strength-outline"
+	}
+	return fmt.Sprintf("mdi-wifi-strength-%d", min(bars, 4))
+}
+
+// wifiNetworks holds the known networks while the list is open in the
+// detail view.
+type wifiNetworks struct {
+	mu       sync.Mutex
+	open     bool
+	networks []nm.Network
+	err      error
+	rerender func()
+}
+
+func (w *wifiNetworks) get() (open bool, networks []nm.Network, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.open, w.networks, w.err
+}
+
+// toggle opens or closes the list, loading the networks when opening.
+func (w *wifiNetworks) toggle(iface string) {
+	w.mu.Lock()
+	w.open = !w.open
+	w.networks, w.err = nil, nil
+	open := w.open
+	w.mu.Unlock()
+	w.rerender()
+	if !open {
+		return
+	}
+
+	networks, err := nm.KnownNetworks(iface)
+	w.mu.Lock()
+	w.networks, w.err = networks, err
+	w.mu.Unlock()
+	w.rerender()
+}
+
+// activate switches iface to network and closes the list, unless that fails.
+func (w *wifiNetworks) activate(network nm.Network, iface string) {
+	err := nm.Activate(network, iface)
+	w.mu.Lock()
+	w.err = err
+	if err == nil {
+		w.open = false
+	}
+	w.mu.Unlock()
+	w.rerender()
+}
+
+func wifiStatus() (bar.Module, bar.Module) {
+	wifi := wlan.Any()
+	portal := &captivePortal{}
+	networks := &wifiNetworks{}
+	var format func(wlan.Info) bar.Output
+	rerender := func() { wifi.Output(format) }
+	portal.rerender = rerender
+	networks.rerender = rerender
+
+	// connected renders a connected network, it is repeated to follow the
+	// signal strength
+	connected := func(i wlan.Info) bar.Output {
+		state, login := portal.status(i.SSID + "/" + i.AccessPointMAC)
+		openLogin := click.Left(func() {
+			_ = exec.Command("xdg-open", login).Start()
+		})
+		signal, err := wireless.Read(i.Name)
+		icon := signalIcon(signal.Bars())
+		if err != nil {
+			icon = "mdi-wifi"
+		}
+
+		out := outputs.Group()
+		// First segment shown in summary mode only.
+		if state == portalCaptive {
+			out.Append(outputs.Pango(
+				fontIcon("mdi-wifi-alert").Alpha(0.6),
+				pango.Text(truncate(i.SSID, -9)),
+			).Color(colorWarn).OnClick(openLogin))
+		} else {
+			summary := outputs.Pango(
+				fontIcon(icon).Alpha(0.6),
+				pango.Text(truncate(i.SSID, -9)),
+			).OnClick(click.Left(func() {
+				toggleMode("network")
+			}))
+			if err == nil && signal.Bars() <= 1 {
+				summary.Color(colorWarn)
+			}
+			out.Append(summary)
+		}
+		// Full name, signal, frequency, bssid in detail mode. Clicking the
+		// name lists the known networks to switch to.
+		out.Append(outputs.Pango(
+			fontIcon(icon).Alpha(0.6),
+			pango.Text(i.SSID),
+		).OnClick(click.Left(func() {
+			// NetworkManager calls block, keep them off the event loop
+			go networks.toggle(i.Name)
+		})))
+		if err == nil {
+			out.Append(outputs.Pango(
+				pango.Textf("%d%%", signal.Percent()), spacer,
+				pango.Textf("%.0f dBm", signal.Level).Small(),
+			))
+		}
+		out.Append(outputs.Textf("%2.1fG", i.Frequency.Gigahertz()))
+		out.Append(outputs.Pango(
+			fontIcon("mdi-access-point").Alpha(0.8),
+			pango.Text(i.AccessPointMAC).Small(),
+		))
+		if state == portalCaptive {
+			out.Append(outputs.Pango(
+				fontIcon("mdi-web").Alpha(0.8), spacer,
+				pango.Text("log in"),
+			).Color(colorWarn).OnClick(openLogin))
+		}
+
+		open, known, nmErr := networks.get()
+		if nmErr != nil {
+			out.Append(outputs.Text(truncate(nmErr.Error(), 40)).Color(colorAlert))
+		}
+		if !open {
+			return out
+		}
+		for _, n := range known {
+			icon := signalIcon((n.Strength + 12) / 25)
+			if !n.InRange() {
+				icon = "mdi-wifi-off"
+			}
+			item := outputs.Pango(fontIcon(icon).Alpha(0.6), spacer, pango.Text(n.ID))
+			switch {
+			case n.SSID == i.SSID:
+				item.Color(colorOk)
+			case !n.InRange():
+				item.Color(colorMuted)
+			}
+			out.Append(item.OnClick(click.Left(func() {
+				go networks.activate(n, i.Name)
+			})))
+		}
+		return out
+	}
+
+	format = func(i wlan.Info) bar.Output {
+		if !i.Connecting() && !i.Connected() {
+			portal.reset()
+			setModeOutput("network", makeIconOutput("mdi-ethernet"))
+			return nil
+		}
+		setModeOutput("network", makeIconOutput("mdi-wifi"))
+		if i.Connecting() {
+			return outputs.Pango(fontIcon("mdi-wifi").Alpha(0.6), "...").
+				Color(colorWarn)
+		}
+		return outputs.Repeat(func(time.Time) bar.Output {
+			return connected(i)
+		}).Every(wifiSignalRefresh)
+	}
+	return split.New(wifi.Output(format), 1)
+}
@@ -0,0 +1,181 @@
+// Package wireguard provides a module for the peers of a WireGuard interface.
+package wireguard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/barista-run/barista/bar"
+	"github.com/barista-run/barista/base/value"
+	l "github.com/barista-run/barista/logging"
+	"github.com/barista-run/barista/outputs"
+	"github.com/barista-run/barista/timing"
+	"github.com/martinlindhe/unit"
+)
+
+// command: wg show all dump
+// output: dump.txt
+//
+// One line per interface with its private key, public key, listen port and
+// fwmark, followed by one line per peer with its public key, preshared key,
+// endpoint, allowed IPs, latest handshake, rx, tx and persistent keepalive.
+// Every line starts with the interface name.
+
+// Peer is a WireGuard peer.
+type Peer struct {
+	PublicKey  string
+	Endpoint   string
+	AllowedIPs []string
+	// LatestHandshake is zero if there was no handshake yet.
+	LatestHandshake time.Time
+	Rx, Tx          unit.Datasize
+}
+
+// HandshakeAge returns the time since the latest handshake.
+func (p Peer) HandshakeAge(now time.Time) time.Duration {
+	if p.LatestHandshake.IsZero() {
+		return -1
+	}
+	return now.Sub(p.LatestHandshake)
+}
+
+// State is the state of a WireGuard interface.
+type State struct {
+	Interface string
+	Peers     []Peer
+	Err       error
+}
+
+// ParseDump returns the peers of iface from the output of `wg show all dump`.
+func ParseDump(r io.Reader, iface string) ([]Peer, error) {
+	var peers []Peer
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Split(s.Text(), "\t")
+		if fields[0] != iface || len(fields) != 9 {
+			// interface lines have 5 fields
+			continue
+		}
+		peer := Peer{PublicKey: fields[1]}
+		if fields[3] != "(none)" {
+			peer.Endpoint = fields[3]
+		}
+		if fields[4] != "(none)" {
+			peer.AllowedIPs = strings.Split(fields[4], ",")
+		}
+		handshake, err := strconv.ParseInt(fields[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid handshake %q: %w", fields[5], err)
+		}
+		if handshake > 0 {
+			peer.LatestHandshake = time.Unix(handshake, 0)
+		}
+		rx, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rx %q: %w", fields[6], err)
+		}
+		tx, err := strconv.ParseUint(fields[7], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tx %q: %w", fields[7], err)
+		}
+		peer.Rx = unit.Datasize(rx) * unit.Byte
+		peer.Tx = unit.Datasize(tx) * unit.Byte
+		peers = append(peers, peer)
+	}
+	return peers, s.Err()
+}
+
+// Module shows the peers of a WireGuard interface.
+type Module struct {
+	iface      string
+	command    []string
+	done       <-chan struct{}
+	scheduler  *timing.Scheduler
+	outputFunc value.Value
+}
+
+// New creates a module for the peers of iface.
+func New(iface string) *Module {
+	m := &Module{
+		iface:     iface,
+		command:   []string{"wg"},
+		scheduler: timing.NewScheduler(),
+	}
+	l.Label(m, iface)
+	l.Register(m, "outputFunc")
+	m.RefreshInterval(30 * time.Second)
+
+	m.Output(func(s State) bar.Output {
+		return outputs.Textf("%s: %d peers", s.Interface, len(s.Peers))
+	})
+
+	return m
+}
+
+// Command sets the wg command, e.g. "sudo", "-n", "wg" since reading the
+// peers needs CAP_NET_ADMIN.
+func (m *Module) Command(command ...string) *Module {
+	if len(command) > 0 {
+		m.command = command
+	}
+	return m
+}
+
+// Output configures a module to display the output of a user-defined function.
+func (m *Module) Output(outputFunc func(State) bar.Output) *Module {
+	m.outputFunc.Set(outputFunc)
+	return m
+}
+
+// RefreshInterval configures the polling frequency.
+func (m *Module) RefreshInterval(interval time.Duration) *Module {
+	m.scheduler.Every(interval)
+	return m
+}
+
+// Until ends Stream once done is closed, e.g. when the module is replaced
+// after a config change.
+func (m *Module) Until(done <-chan struct{}) *Module {
+	m.done = done
+	return m
+}
+
+// Stream starts the module.
+func (m *Module) Stream(s bar.Sink) {
+	state := m.getState()
+
+	outputFunc := m.outputFunc.Get().(func(State) bar.Output)
+	nextOutputFunc, done := m.outputFunc.Subscribe()
+	defer done()
+	defer m.scheduler.Close()
+
+	for {
+		s.Output(outputFunc(state))
+		select {
+		case <-m.scheduler.C:
+			state = m.getState()
+		case <-nextOutputFunc:
+			outputFunc = m.outputFunc.Get().(func(State) bar.Output)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *Module) getState() State {
+	state := State{Interface: m.iface}
+	args := append(append([]string{}, m.command[1:]...), "show", "all", "dump")
+	out, err := exec.Command(m.command[0], args...).Output()
+	if err != nil {
+		state.Err = err
+		return state
+	}
+	state.Peers, state.Err = ParseDump(bytes.NewReader(out), m.iface)
+	return state
+}
@@ -0,0 +1,67 @@
+package wireguard
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/martinlindhe/unit"
+)
+
+func TestParseDump(t *testing.T) {
+	f, err := os.Open("dump.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	peers, err := ParseDump(f, "wg0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the interface line is skipped, only the two peers remain
+	want := []Peer{
+		{
+			PublicKey:       "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",
+			Endpoint:        "203.0.113.7:51820",
+			AllowedIPs:      []string{"10.8.0.0/24", "fd00:8::/64"},
+			LatestHandshake: time.Unix(1760781600, 0),
+			Rx:              1532884 * unit.Byte,
+			Tx:              8421379 * unit.Byte,
+		},
+		{
+			PublicKey:  "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=",
+			AllowedIPs: []string{"10.9.0.2/32"},
+		},
+	}
+	if !reflect.DeepEqual(peers, want) {
+		t.Errorf("ParseDump() = %+v, want %+v", peers, want)
+	}
+
+	if len(peers) == 2 {
+		if !peers[1].LatestHandshake.IsZero() {
+			t.Errorf("peer without handshake: LatestHandshake = %v, want zero", peers[1].LatestHandshake)
+		}
+		if age := peers[1].HandshakeAge(time.Now()); age != -1 {
+			t.Errorf("peer without handshake: HandshakeAge() = %v, want -1", age)
+		}
+	}
+}
+
+func TestParseDumpOtherInterface(t *testing.T) {
+	f, err := os.Open("dump.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	peers, err := ParseDump(f, "wg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 0 {
+		t.Errorf("ParseDump(wg1) = %+v, want no peers", peers)
+	}
+}
@@ -0,0 +1,80 @@
+// Package wireless reads the link quality of wireless interfaces from
+// /proc/net/wireless.
+package wireless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// file: /proc/net/wireless
+//
+//	Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE
+//	 face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22
+//	wlan0: 0000   54.  -56.  -256        0      0      0      0     37        0
+
+// maxQuality is the link quality scale most drivers use.
+const maxQuality = 70
+
+// Signal is the link quality of a wireless interface.
+type Signal struct {
+	// Quality is the link quality, 0 to 70 for most drivers.
+	Quality float64
+	// Level is the signal level in dBm.
+	Level float64
+}
+
+// Percent returns the link quality in percent.
+func (s Signal) Percent() int {
+	p := int(s.Quality * 100 / maxQuality)
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+// Bars returns the link quality as 0 to 4 bars.
+func (s Signal) Bars() int {
+	return (s.Percent() + 12) / 25
+}
+
+// Read returns the signal of iface.
+func Read(iface string) (Signal, error) {
+	f, err := os.Open("/proc/net/wireless")
+	if err != nil {
+		return Signal{}, err
+	}
+	defer f.Close()
+	return parse(f, iface)
+}
+
+func parse(r io.Reader, iface string) (Signal, error) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		name, rest, ok := strings.Cut(s.Text(), ":")
+		if !ok || strings.TrimSpace(name) != iface {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 3 {
+			return Signal{}, fmt.Errorf("invalid line for %s: %q", iface, s.Text())
+		}
+		quality, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "."), 64)
+		if err != nil {
+			return Signal{}, err
+		}
+		level, err := strconv.ParseFloat(strings.TrimSuffix(fields[2], "."), 64)
+		if err != nil {
+			return Signal{}, err
+		}
+		return Signal{Quality: quality, Level: level}, nil
+	}
+	if err := s.Err(); err != nil {
+		return Signal{}, err
+	}
+	return Signal{}, fmt.Errorf("%s not found in /proc/net/wireless", iface)
+}
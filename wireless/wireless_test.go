@@ -0,0 +1,53 @@
+package wireless
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		iface   string
+		want    Signal
+		percent int
+		bars    int
+	}{
+		{"wlan0", Signal{Quality: 54, Level: -56}, 77, 3},
+		{"wlp3s0", Signal{Quality: 70, Level: -38}, 100, 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.iface, func(t *testing.T) {
+			f, err := os.Open("wireless.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			s, err := parse(f, tc.iface)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tc.want {
+				t.Errorf("parse(%s) = %+v, want %+v", tc.iface, s, tc.want)
+			}
+			if p := s.Percent(); p != tc.percent {
+				t.Errorf("Percent() = %d, want %d", p, tc.percent)
+			}
+			if b := s.Bars(); b != tc.bars {
+				t.Errorf("Bars() = %d, want %d", b, tc.bars)
+			}
+		})
+	}
+}
+
+func TestParseMissingInterface(t *testing.T) {
+	f, err := os.Open("wireless.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := parse(f, "wlan1"); err == nil {
+		t.Error("parse(wlan1) succeeded, want an error")
+	}
+}